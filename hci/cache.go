@@ -0,0 +1,315 @@
+package hci
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	hc "github.com/hypertec-cloud/go-hci"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+// How long listings of offerings, templates and zones are reused
+const catalogueTTL = time.Minute
+
+// resourceCache is shared by all the resources and data sources of a provider. It keeps the
+// hci.Resources of every environment, and memoizes the catalogues that are listed to resolve names.
+// Concurrent lookups of the same key are de-duplicated. Errors are never cached.
+type resourceCache struct {
+	mu           sync.Mutex
+	environments map[string]hci.Resources
+	catalogues   map[string]catalogueEntry
+	flights      map[string]*flight
+}
+
+type catalogueEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// A lookup in progress, waited on by the callers asking for the same key
+type flight struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newResourceCache() *resourceCache {
+	return &resourceCache{
+		environments: map[string]hci.Resources{},
+		catalogues:   map[string]catalogueEntry{},
+		flights:      map[string]*flight{},
+	}
+}
+
+// Calls load once for all the concurrent callers asking for the same key. The load runs apart from the callers, so
+// that a caller giving up when its context is done doesn't fail the others waiting for it.
+func (cache *resourceCache) do(ctx context.Context, key string, load func() (interface{}, error)) (interface{}, error) {
+	cache.mu.Lock()
+	f, ok := cache.flights[key]
+	if !ok {
+		f = &flight{done: make(chan struct{})}
+		cache.flights[key] = f
+		go func() {
+			f.value, f.err = load()
+			cache.mu.Lock()
+			delete(cache.flights, key)
+			cache.mu.Unlock()
+			close(f.done)
+		}()
+	}
+	cache.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Returns the hci.Resources of the environment, bound to the context
+func (cache *resourceCache) getResources(ctx context.Context, client *hc.HciClient, environmentID string) (hci.Resources, error) {
+	cache.mu.Lock()
+	resources, ok := cache.environments[environmentID]
+	cache.mu.Unlock()
+	if !ok {
+		// the lookup is shared with the other callers, so it isn't bound to this caller's context
+		detachedClient := client.WithContext(context.Background())
+		value, err := cache.do(ctx, "environment/"+environmentID, func() (interface{}, error) {
+			environment, err := detachedClient.Environments.Get(environmentID)
+			if err != nil {
+				return nil, err
+			}
+			resources, err := detachedClient.GetResources(environment.ServiceConnection.ServiceCode, environment.Name)
+			if err != nil {
+				return nil, err
+			}
+			cache.mu.Lock()
+			cache.environments[environmentID] = resources.(hci.Resources)
+			cache.mu.Unlock()
+			return resources, nil
+		})
+		if err != nil {
+			return hci.Resources{}, err
+		}
+		resources = value.(hci.Resources)
+	}
+	bound := resources.WithContext(ctx)
+	cache.wrapCatalogues(ctx, &bound, resources.WithContext(context.Background()), environmentID)
+	return bound, nil
+}
+
+// Forgets everything cached for the environment, when it is updated or deleted
+func (cache *resourceCache) invalidateEnvironment(environmentID string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	delete(cache.environments, environmentID)
+	prefix := environmentID + "/"
+	for key := range cache.catalogues {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
+			delete(cache.catalogues, key)
+		}
+	}
+}
+
+// Returns the memoized catalogue, or loads it if it is missing or expired
+func (cache *resourceCache) catalogue(ctx context.Context, key string, load func() (interface{}, error)) (interface{}, error) {
+	cache.mu.Lock()
+	entry, ok := cache.catalogues[key]
+	cache.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+	return cache.do(ctx, "catalogue/"+key, func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		cache.mu.Lock()
+		cache.catalogues[key] = catalogueEntry{value: value, expires: time.Now().Add(catalogueTTL)}
+		cache.mu.Unlock()
+		return value, nil
+	})
+}
+
+// Replaces the catalogue services of the resources bound to the context by cached ones. The catalogues are loaded
+// with the detached resources, which aren't bound to any caller's context.
+func (cache *resourceCache) wrapCatalogues(ctx context.Context, resources *hci.Resources, detached hci.Resources, environmentID string) {
+	prefix := environmentID + "/"
+	resources.Templates = cachedTemplateService{resources.Templates, detached.Templates, ctx, cache, prefix + "templates"}
+	resources.ComputeOfferings = cachedComputeOfferingService{resources.ComputeOfferings, detached.ComputeOfferings, ctx, cache, prefix + "compute_offerings"}
+	resources.DiskOfferings = cachedDiskOfferingService{resources.DiskOfferings, detached.DiskOfferings, ctx, cache, prefix + "disk_offerings"}
+	resources.VpcOfferings = cachedVpcOfferingService{resources.VpcOfferings, detached.VpcOfferings, ctx, cache, prefix + "vpc_offerings"}
+	resources.NetworkOfferings = cachedNetworkOfferingService{resources.NetworkOfferings, detached.NetworkOfferings, ctx, cache, prefix + "network_offerings"}
+	resources.Zones = cachedZoneService{resources.Zones, detached.Zones, ctx, cache, prefix + "zones"}
+}
+
+// Returns the key of a listing with the options, the key of the full listing if there are none
+func optionsKey(key string, options map[string]string) string {
+	if len(options) == 0 {
+		return key
+	}
+	values := url.Values{}
+	for name, value := range options {
+		values.Set(name, value)
+	}
+	return key + "?" + values.Encode()
+}
+
+// The cached services below memoize List, as well as ListWithOptions and Get for the templates and the compute and disk
+// offerings that the data sources and resources look up. The returned values are copies, so callers may modify them.
+
+type cachedTemplateService struct {
+	hci.TemplateService
+	detached hci.TemplateService
+	ctx      context.Context
+	cache    *resourceCache
+	key      string
+}
+
+func (service cachedTemplateService) Get(id string) (*hci.Template, error) {
+	value, err := service.cache.catalogue(service.ctx, service.key+"/"+id, func() (interface{}, error) {
+		return service.detached.Get(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	template := *value.(*hci.Template)
+	return &template, nil
+}
+
+func (service cachedTemplateService) List() ([]hci.Template, error) {
+	return service.ListWithOptions(map[string]string{})
+}
+
+func (service cachedTemplateService) ListWithOptions(options map[string]string) ([]hci.Template, error) {
+	value, err := service.cache.catalogue(service.ctx, optionsKey(service.key, options), func() (interface{}, error) {
+		return service.detached.ListWithOptions(options)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]hci.Template(nil), value.([]hci.Template)...), nil
+}
+
+type cachedComputeOfferingService struct {
+	hci.ComputeOfferingService
+	detached hci.ComputeOfferingService
+	ctx      context.Context
+	cache    *resourceCache
+	key      string
+}
+
+func (service cachedComputeOfferingService) Get(id string) (*hci.ComputeOffering, error) {
+	value, err := service.cache.catalogue(service.ctx, service.key+"/"+id, func() (interface{}, error) {
+		return service.detached.Get(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	offering := *value.(*hci.ComputeOffering)
+	return &offering, nil
+}
+
+func (service cachedComputeOfferingService) List() ([]hci.ComputeOffering, error) {
+	return service.ListWithOptions(map[string]string{})
+}
+
+func (service cachedComputeOfferingService) ListWithOptions(options map[string]string) ([]hci.ComputeOffering, error) {
+	value, err := service.cache.catalogue(service.ctx, optionsKey(service.key, options), func() (interface{}, error) {
+		return service.detached.ListWithOptions(options)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]hci.ComputeOffering(nil), value.([]hci.ComputeOffering)...), nil
+}
+
+type cachedDiskOfferingService struct {
+	hci.DiskOfferingService
+	detached hci.DiskOfferingService
+	ctx      context.Context
+	cache    *resourceCache
+	key      string
+}
+
+func (service cachedDiskOfferingService) Get(id string) (*hci.DiskOffering, error) {
+	value, err := service.cache.catalogue(service.ctx, service.key+"/"+id, func() (interface{}, error) {
+		return service.detached.Get(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	offering := *value.(*hci.DiskOffering)
+	return &offering, nil
+}
+
+func (service cachedDiskOfferingService) List() ([]hci.DiskOffering, error) {
+	return service.ListWithOptions(map[string]string{})
+}
+
+func (service cachedDiskOfferingService) ListWithOptions(options map[string]string) ([]hci.DiskOffering, error) {
+	value, err := service.cache.catalogue(service.ctx, optionsKey(service.key, options), func() (interface{}, error) {
+		return service.detached.ListWithOptions(options)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]hci.DiskOffering(nil), value.([]hci.DiskOffering)...), nil
+}
+
+type cachedVpcOfferingService struct {
+	hci.VpcOfferingService
+	detached hci.VpcOfferingService
+	ctx      context.Context
+	cache    *resourceCache
+	key      string
+}
+
+func (service cachedVpcOfferingService) List() ([]hci.VpcOffering, error) {
+	value, err := service.cache.catalogue(service.ctx, service.key, func() (interface{}, error) {
+		return service.detached.List()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]hci.VpcOffering(nil), value.([]hci.VpcOffering)...), nil
+}
+
+type cachedNetworkOfferingService struct {
+	hci.NetworkOfferingService
+	detached hci.NetworkOfferingService
+	ctx      context.Context
+	cache    *resourceCache
+	key      string
+}
+
+func (service cachedNetworkOfferingService) List() ([]hci.NetworkOffering, error) {
+	value, err := service.cache.catalogue(service.ctx, service.key, func() (interface{}, error) {
+		return service.detached.List()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]hci.NetworkOffering(nil), value.([]hci.NetworkOffering)...), nil
+}
+
+type cachedZoneService struct {
+	hci.ZoneService
+	detached hci.ZoneService
+	ctx      context.Context
+	cache    *resourceCache
+	key      string
+}
+
+func (service cachedZoneService) List() ([]hci.Zone, error) {
+	value, err := service.cache.catalogue(service.ctx, service.key, func() (interface{}, error) {
+		return service.detached.List()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]hci.Zone(nil), value.([]hci.Zone)...), nil
+}
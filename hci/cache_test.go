@@ -0,0 +1,141 @@
+package hci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCacheMeta(t *testing.T) (map[string]*int32, interface{}) {
+	calls := map[string]*int32{"environments": new(int32), "templates": new(int32), "template": new(int32)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/environments/"):
+			atomic.AddInt32(calls["environments"], 1)
+			time.Sleep(20 * time.Millisecond)
+			w.Write([]byte(`{"data":{"id":"` + environmentID + `","name":"env","serviceConnection":{"serviceCode":"hci"}}}`))
+		case strings.HasSuffix(r.URL.Path, "/templates"):
+			atomic.AddInt32(calls["templates"], 1)
+			w.Write([]byte(`{"data":[{"id":"` + environmentID + `","name":"Ubuntu"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/templates/"+environmentID):
+			atomic.AddInt32(calls["template"], 1)
+			w.Write([]byte(`{"data":{"id":"` + environmentID + `","name":"Ubuntu"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"errorCode":"NOT_FOUND","message":"not found"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	config := Config{APIURL: server.URL, APIKey: "key"}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return calls, &providerMeta{client: client, cache: newResourceCache()}
+}
+
+func TestCacheEnvironmentLookups(t *testing.T) {
+	calls, meta := newTestCacheMeta(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := getResourcesForEnvironmentID(context.Background(), meta, environmentID); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if *calls["environments"] != 1 {
+		t.Errorf("expected 1 environment lookup, got %d", *calls["environments"])
+	}
+
+	meta.(*providerMeta).cache.invalidateEnvironment(environmentID)
+	if _, err := getResourcesForEnvironmentID(context.Background(), meta, environmentID); err != nil {
+		t.Fatal(err)
+	}
+	if *calls["environments"] != 2 {
+		t.Errorf("expected 2 environment lookups after invalidation, got %d", *calls["environments"])
+	}
+}
+
+func TestCacheCatalogues(t *testing.T) {
+	calls, meta := newTestCacheMeta(t)
+	for i := 0; i < 3; i++ {
+		hciResources, err := getResourcesForEnvironmentID(context.Background(), meta, environmentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		templateID, err := retrieveTemplateID(&hciResources, "ubuntu")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if templateID != environmentID {
+			t.Errorf("expected template %s, got %s", environmentID, templateID)
+		}
+	}
+	if *calls["templates"] != 1 {
+		t.Errorf("expected 1 template listing, got %d", *calls["templates"])
+	}
+}
+
+func TestCacheTemplateLookups(t *testing.T) {
+	calls, meta := newTestCacheMeta(t)
+	for i := 0; i < 3; i++ {
+		hciResources, err := getResourcesForEnvironmentID(context.Background(), meta, environmentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := hciResources.Templates.ListWithOptions(map[string]string{"name": "Ubuntu"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := hciResources.Templates.ListWithOptions(map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := hciResources.Templates.List(); err != nil {
+			t.Fatal(err)
+		}
+		template, err := hciResources.Templates.Get(environmentID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if template.Name != "Ubuntu" {
+			t.Errorf("expected template Ubuntu, got %s", template.Name)
+		}
+	}
+	// one listing with the options, and one without any shared with List
+	if *calls["templates"] != 2 {
+		t.Errorf("expected 2 template listings, got %d", *calls["templates"])
+	}
+	if *calls["template"] != 1 {
+		t.Errorf("expected 1 template lookup, got %d", *calls["template"])
+	}
+}
+
+func TestCacheLookupNotFailedByCancelledCaller(t *testing.T) {
+	calls, meta := newTestCacheMeta(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := getResourcesForEnvironmentID(ctx, meta, environmentID)
+		cancelled <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+	cancel()
+
+	if _, err := getResourcesForEnvironmentID(context.Background(), meta, environmentID); err != nil {
+		t.Errorf("expected the lookup to succeed despite the cancelled caller, got %s", err)
+	}
+	if err := <-cancelled; err != context.Canceled {
+		t.Errorf("expected the cancelled caller to fail with %s, got %v", context.Canceled, err)
+	}
+	if *calls["environments"] != 1 {
+		t.Errorf("expected 1 environment lookup, got %d", *calls["environments"])
+	}
+}
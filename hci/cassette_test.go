@@ -0,0 +1,419 @@
+package hci
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	hc "github.com/hypertec-cloud/go-hci"
+	"github.com/hypertec-cloud/go-hci/api"
+)
+
+// Acceptance tests record their requests and responses to a cassette per test when HCI_RECORD=1, and
+// otherwise replay them without an API key nor a network connection. Tests without a cassette run against
+// the API when HCI_API_KEY is set, and HCI_LIVE=1 runs every test against the API, leaving the cassettes
+// untouched. Tests using cassettes run one at a time.
+const (
+	hciRecord    = "HCI_RECORD"
+	hciLive      = "HCI_LIVE"
+	cassettesDir = "testdata/cassettes"
+
+	// Variable of a cassette holding the nth name returned by testAccResourceName
+	resourceNameVariable = "resource_name_%d"
+)
+
+// How an acceptance test sends its requests
+type testMode int
+
+const (
+	// Requests are answered by the cassette of the test
+	replayMode testMode = iota
+	// Requests are sent to the API and recorded to the cassette of the test
+	recordMode
+	// Requests are sent to the API, the cassette isn't used
+	liveMode
+	// There is neither a cassette to replay nor an API key, the test is skipped
+	skipMode
+)
+
+var (
+	// Held by the test using a cassette
+	cassetteLock sync.Mutex
+	// The cassette of the running test, answering the requests of testAccProvider
+	cassettes = &cassetteTransport{}
+	// Names returned by testAccResourceName for each test
+	testNames   = map[string][]string{}
+	testNamesMu sync.Mutex
+)
+
+func recordingCassettes() bool {
+	return os.Getenv(hciRecord) == "1"
+}
+
+// Returns how the test sends its requests
+func accTestMode(t *testing.T) testMode {
+	switch {
+	case recordingCassettes():
+		return recordMode
+	case os.Getenv(hciLive) == "1":
+		return liveMode
+	}
+	if _, err := os.Stat(cassettePath(t)); !os.IsNotExist(err) {
+		return replayMode
+	}
+	if hasEnvValue(hciAPIKey) {
+		return liveMode
+	}
+	return skipMode
+}
+
+// Sends the requests to the recorder of the running test
+type cassetteTransport struct {
+	mu       sync.Mutex
+	mode     testMode
+	recorder *api.Recorder
+}
+
+// Wraps the transport of a client, which the recorder of the running test sends the recorded requests with
+func (transport *cassetteTransport) wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		transport.mu.Lock()
+		recorder := transport.recorder
+		transport.mu.Unlock()
+		if recorder == nil {
+			return nil, fmt.Errorf("No cassette in use for %s %s", req.Method, req.URL)
+		}
+		return recorder.Wrap(next).RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func (transport *cassetteTransport) use(mode testMode, recorder *api.Recorder) {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.mode = mode
+	transport.recorder = recorder
+}
+
+func (transport *cassetteTransport) currentMode() testMode {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	return transport.mode
+}
+
+// Configures testAccProvider like the provider, with the transport of its client wrapped by the cassette of
+// the running test, unless the test runs against the API
+func configureWithCassettes(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	mode := cassettes.currentMode()
+	if mode == liveMode {
+		return providerConfigure(ctx, d)
+	}
+	if mode == replayMode && d.Get("api_key").(string) == "" {
+		// replayed requests are never sent, any key will do
+		if err := d.Set("api_key", api.REDACTED); err != nil {
+			return nil, diag.FromErr(err)
+		}
+	}
+	config, diags := providerClientConfig(ctx, d)
+	if diags.HasError() {
+		return nil, diags
+	}
+	config.WrapTransport = cassettes.wrap
+	if mode == replayMode {
+		// a request missing from the cassette fails the same way every time
+		config.MaxRetries = 0
+	}
+	return newProviderMeta(ctx, d, config)
+}
+
+// Returns the path of the cassette of the test
+func cassettePath(t *testing.T) string {
+	return filepath.Join(cassettesDir, strings.ReplaceAll(t.Name(), "/", "_")+".json")
+}
+
+// Records the requests of the test to its cassette, or replays them. The test is skipped if there is
+// no cassette to replay nor an API key. Other tests using cassettes wait until the test is done.
+func useCassette(t *testing.T) {
+	mode := accTestMode(t)
+	path := cassettePath(t)
+	if mode == skipMode {
+		t.Skipf("No cassette %s to replay, record it with %s=1 or run the test against the API with %s", path, hciRecord, hciAPIKey)
+	}
+
+	cassetteLock.Lock()
+	if mode == liveMode {
+		cassettes.use(mode, nil)
+		t.Cleanup(func() {
+			defer cassetteLock.Unlock()
+			cassettes.use(replayMode, nil)
+		})
+		return
+	}
+	recorderMode := api.REPLAY
+	if mode == recordMode {
+		recorderMode = api.RECORD
+	}
+	recorder, err := api.NewRecorder(path, recorderMode, nil)
+	if err != nil {
+		cassetteLock.Unlock()
+		t.Fatal(err)
+	}
+	cassettes.use(mode, recorder)
+	t.Cleanup(func() {
+		defer cassetteLock.Unlock()
+		cassettes.use(replayMode, nil)
+		testNamesMu.Lock()
+		for i, name := range testNames[t.Name()] {
+			recorder.SetVariable(fmt.Sprintf(resourceNameVariable, i), name)
+		}
+		testNamesMu.Unlock()
+		if err := recorder.Stop(); err != nil {
+			t.Errorf("Error writing cassette %s: %s", path, err)
+		}
+	})
+}
+
+// Returns a random name for the resources of an acceptance test, so that resources leaked by a failed
+// run don't conflict with the next run. The names are stored in the cassette when recording, and read
+// back from it when replaying so that replayed tests send the same requests as the recorded ones.
+func testAccResourceName(t *testing.T) string {
+	testNamesMu.Lock()
+	n := len(testNames[t.Name()])
+	testNamesMu.Unlock()
+	if n == 0 {
+		t.Cleanup(func() {
+			testNamesMu.Lock()
+			defer testNamesMu.Unlock()
+			delete(testNames, t.Name())
+		})
+	}
+
+	name := testAccResourcePrefix + acctest.RandString(10)
+	if accTestMode(t) == replayMode {
+		path := cassettePath(t)
+		recorder, err := api.NewRecorder(path, api.REPLAY, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ok bool
+		if name, ok = recorder.Variable(fmt.Sprintf(resourceNameVariable, n)); !ok {
+			t.Fatalf("Cassette %s has no name %d, record it again with %s=1", path, n, hciRecord)
+		}
+	}
+
+	testNamesMu.Lock()
+	testNames[t.Name()] = append(testNames[t.Name()], name)
+	testNamesMu.Unlock()
+	return name
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	server := newFakeAPI(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := api.NewRecorder(path, api.RECORD, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := hc.NewHciClientWithApiClient(api.NewApiClientWithOptions(server.server.URL, fakeAPIKey, api.WithTransport(recorder)))
+	environment, err := client.Environments.Get(server.environmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Users.ListWithOptions(map[string]string{"organizationId": environment.Organization.Id, "b": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), fakeAPIKey) {
+		t.Errorf("expected the API key to be scrubbed from the cassette:\n%s", data)
+	}
+
+	// the fake API is stopped, every request must be answered by the cassette
+	server.server.Close()
+	recorder, err = api.NewRecorder(path, api.REPLAY, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = hc.NewHciClientWithApiClient(api.NewApiClientWithOptions(server.server.URL, "another-key",
+		api.WithTransport(recorder), api.WithRetry(0, 0)))
+	replayed, err := client.Environments.Get(server.environmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Name != environment.Name || replayed.Organization.Id != environment.Organization.Id {
+		t.Errorf("expected the recorded environment %+v, got %+v", environment, replayed)
+	}
+	users, err := client.Users.ListWithOptions(map[string]string{"b": "2", "organizationId": environment.Organization.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Username != fakeUsername {
+		t.Errorf("expected the recorded users, got %+v", users)
+	}
+	if _, err := client.Environments.Get("missing"); err == nil {
+		t.Error("expected an error for a request missing from the cassette")
+	}
+}
+
+func TestCassetteReplaysInOrder(t *testing.T) {
+	server := newFakeAPI(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := api.NewRecorder(path, api.RECORD, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiClient := api.NewApiClientWithOptions(server.server.URL, fakeAPIKey, api.WithTransport(recorder))
+	endpoint := "services/" + fakeServiceCode + "/" + fakeEnvironmentName + "/sshkeys/foobar"
+	get := func(apiClient api.ApiClient) int {
+		response, err := apiClient.Do(api.HciRequest{Endpoint: endpoint})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response.StatusCode
+	}
+	get(apiClient)
+	server.add("sshkeys", fakeEntity{"id": "foobar", "name": "foobar"})
+	get(apiClient)
+	if err := recorder.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder, err = api.NewRecorder(path, api.REPLAY, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiClient = api.NewApiClientWithOptions(server.server.URL, fakeAPIKey, api.WithTransport(recorder))
+	for i, expected := range []int{http.StatusNotFound, http.StatusOK, http.StatusOK} {
+		if status := get(apiClient); status != expected {
+			t.Errorf("expected replayed request %d to have the status %d, got %d", i, expected, status)
+		}
+	}
+}
+
+func TestCassetteRedactsSecretsAndKeepsVariables(t *testing.T) {
+	const zones = `{"data":[{"name":"qc-1","id":12345678901234567891}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/zones") {
+			w.Write([]byte(zones))
+			return
+		}
+		w.Write([]byte(`{"data":{"username":"foobar","password":"generated-password","quota":12345678901234567891}}`))
+	}))
+	t.Cleanup(server.Close)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder, err := api.NewRecorder(path, api.RECORD, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.SetVariable(fmt.Sprintf(resourceNameVariable, 0), testAccResourcePrefix+"foobar")
+	apiClient := api.NewApiClientWithOptions(server.URL, fakeAPIKey, api.WithTransport(recorder))
+	request := api.HciRequest{Method: api.POST, Endpoint: "vpnusers", Body: []byte(`{"username":"foobar","password":"chosen-password"}`)}
+	response, err := apiClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(response.Data), "generated-password") {
+		t.Errorf("expected the recorded response to be returned unchanged, got %s", response.Data)
+	}
+	if _, err := apiClient.Do(api.HciRequest{Method: api.GET, Endpoint: "zones"}); err != nil {
+		t.Fatal(err)
+	}
+	interactions := recorder.Interactions()
+	if !strings.Contains(interactions[0].Response.Body, "12345678901234567891") {
+		t.Errorf("expected the numbers of a redacted body to be kept, got %s", interactions[0].Response.Body)
+	}
+	if interactions[1].Response.Body != zones {
+		t.Errorf("expected a body without secrets to be recorded unchanged, got %s", interactions[1].Response.Body)
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"chosen-password", "generated-password"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("expected %s to be redacted from the cassette:\n%s", secret, data)
+		}
+	}
+
+	server.Close()
+	recorder, err = api.NewRecorder(path, api.REPLAY, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := recorder.Variable(fmt.Sprintf(resourceNameVariable, 0)); name != testAccResourcePrefix+"foobar" {
+		t.Errorf("expected the recorded name, got %q", name)
+	}
+	apiClient = api.NewApiClientWithOptions(server.URL, fakeAPIKey, api.WithTransport(recorder), api.WithRetry(0, 0))
+	response, err = apiClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(response.Data), `"username":"foobar"`) {
+		t.Errorf("expected the recorded response, got %s", response.Data)
+	}
+}
+
+func TestCassetteReplaysInteractionArrays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := `[{"request":{"method":"GET","endpoint":"/zones"},"response":{"statusCode":200,"body":"{\"data\":[]}"}}]`
+	if err := ioutil.WriteFile(path, []byte(cassette), 0644); err != nil {
+		t.Fatal(err)
+	}
+	recorder, err := api.NewRecorder(path, api.REPLAY, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.Interactions()) != 1 {
+		t.Errorf("expected 1 interaction, got %+v", recorder.Interactions())
+	}
+}
+
+func TestCassetteTestMode(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		env      map[string]string
+		expected testMode
+	}{
+		{"no cassette nor API key", map[string]string{}, skipMode},
+		{"no cassette", map[string]string{hciAPIKey: "key"}, liveMode},
+		{"recording", map[string]string{hciAPIKey: "key", hciRecord: "1"}, recordMode},
+		{"live", map[string]string{hciAPIKey: "key", hciLive: "1"}, liveMode},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{hciAPIKey, hciRecord, hciLive} {
+				t.Setenv(key, test.env[key])
+			}
+			if mode := accTestMode(t); mode != test.expected {
+				t.Errorf("expected mode %d, got %d", test.expected, mode)
+			}
+		})
+	}
+}
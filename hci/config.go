@@ -1,6 +1,21 @@
 package hci
 
-import hci "github.com/hypertec-cloud/go-hci"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
+	hci "github.com/hypertec-cloud/go-hci"
+	"github.com/hypertec-cloud/go-hci/api"
+)
+
+const defaultAPIURL = "https://hypertec.cloud/api/v1"
 
 // Config is the configuration structure used to instantiate a
 // new hci client.
@@ -8,12 +23,147 @@ type Config struct {
 	APIURL   string
 	APIKey   string
 	Insecure bool
+	// PEM file and PEM certificates of certificate authorities trusted in addition to the system ones
+	CACertFile string
+	CACertPEM  string
+	// PEM certificate and key of the client for mutual TLS, or paths of PEM files
+	ClientCert string
+	ClientKey  string
+	// HTTP proxy, the proxy of the environment variables when empty
+	ProxyURL string
+	// Timeout of an attempt of a request, 0 for no timeout
+	RequestTimeout time.Duration
+	// How long idle connections are kept open, 0 for no limit
+	IdleConnTimeout time.Duration
+	MaxRetries      int
+	RetryMaxWait    time.Duration
+	// Average number of requests per second, 0 for no limit
+	RequestsPerSecond float64
+	// Maximum number of requests in flight, 0 for no limit
+	MaxConcurrentRequests int
+	// Wraps the transport of the client once it is configured, e.g. to record its requests in tests
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+}
+
+// providerMeta is the meta of the provider, shared by all of its resources and data sources.
+type providerMeta struct {
+	client *hci.HciClient
+	cache  *resourceCache
+	// Defaults of the provider for the resources and data sources that don't set them
+	defaultEnvironmentID    string
+	defaultServiceCode      string
+	defaultOrganizationCode string
 }
 
 // NewClient returns a new HciClient client.
 func (c *Config) NewClient() (*hci.HciClient, error) {
+	options := []api.ClientOption{
+		api.WithRetry(c.MaxRetries, c.RetryMaxWait),
+		api.WithRateLimit(c.RequestsPerSecond),
+		api.WithMaxConcurrentRequests(c.MaxConcurrentRequests),
+	}
+	transportOptions, err := c.transportOptions()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, transportOptions...)
+	if c.WrapTransport != nil {
+		options = append(options, api.WithTransportWrapper(c.WrapTransport))
+	}
+	apiClient := api.NewApiClientWithOptions(c.APIURL, c.APIKey, options...)
+	if logging.IsDebugOrHigher() {
+		apiClient = api.NewLoggingApiClient(apiClient, logging.LogLevel() == "TRACE")
+	}
+	return hci.NewHciClientWithApiClient(apiClient), nil
+}
+
+func (c *Config) transportOptions() ([]api.ClientOption, error) {
+	options := []api.ClientOption{api.WithIdleConnTimeout(c.IdleConnTimeout)}
+	if c.RequestTimeout > 0 {
+		options = append(options, api.WithRequestTimeout(c.RequestTimeout))
+	}
 	if c.Insecure {
-		return hci.NewInsecureHciClientWithURL(c.APIURL, c.APIKey), nil
+		options = append(options, api.WithInsecureSkipVerify())
+	}
+	if c.CACertFile != "" || c.CACertPEM != "" {
+		rootCAs, err := c.loadRootCAs()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, api.WithRootCAs(rootCAs))
+	}
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, fmt.Errorf("client_cert and client_key must be set together")
+		}
+		certificate, err := c.loadClientCertificate()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, api.WithClientCertificate(certificate))
+	}
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid proxy_url %s: %s", c.ProxyURL, err)
+		}
+		options = append(options, api.WithProxy(proxyURL))
+	}
+	return options, nil
+}
+
+func (c *Config) loadRootCAs() (*x509.CertPool, error) {
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if c.CACertFile != "" {
+		path, err := expandHome(c.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		pem, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading ca_cert_file: %s", err)
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No PEM certificate found in ca_cert_file %s", path)
+		}
+	}
+	if c.CACertPEM != "" && !rootCAs.AppendCertsFromPEM([]byte(c.CACertPEM)) {
+		return nil, fmt.Errorf("No PEM certificate found in ca_cert_pem")
+	}
+	return rootCAs, nil
+}
+
+func (c *Config) loadClientCertificate() (tls.Certificate, error) {
+	certPEM, err := readPEM("client_cert", c.ClientCert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := readPEM("client_key", c.ClientKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("Invalid client_cert or client_key: %s", err)
+	}
+	return certificate, nil
+}
+
+// Returns the value if it is PEM encoded, otherwise reads the file it is the path of
+func readPEM(key, value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	path, err := expandHome(value)
+	if err != nil {
+		return nil, err
+	}
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", key, err)
 	}
-	return hci.NewHciClientWithURL(c.APIURL, c.APIKey), nil
+	return pem, nil
 }
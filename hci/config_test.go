@@ -0,0 +1,529 @@
+package hci
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/api"
+)
+
+func newTestRetryClient(t *testing.T, statuses ...int) (*int32, api.ApiClient) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := int(atomic.AddInt32(&attempts, 1))
+		status := statuses[len(statuses)-1]
+		if attempt <= len(statuses) {
+			status = statuses[attempt-1]
+		}
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"data":{}}`))
+		} else {
+			w.Write([]byte(`{"errors":[{"errorCode":"SERVICE_UNAVAILABLE","message":"try again"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	config := Config{
+		APIURL:       server.URL,
+		APIKey:       "key",
+		MaxRetries:   3,
+		RetryMaxWait: time.Second,
+	}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &attempts, client.GetApiClient()
+}
+
+func TestRetryReadOnTransientFailure(t *testing.T) {
+	attempts, client := newTestRetryClient(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+	resp, err := client.Do(api.HciRequest{Method: api.GET, Endpoint: "instances"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if *attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", *attempts)
+	}
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	attempts, client := newTestRetryClient(t, http.StatusTooManyRequests)
+	resp, err := client.Do(api.HciRequest{Method: api.GET, Endpoint: "instances"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", resp.StatusCode)
+	}
+	if *attempts != 4 {
+		t.Errorf("expected 4 attempts, got %d", *attempts)
+	}
+}
+
+func TestNoRetryOfMutationAfterGatewayError(t *testing.T) {
+	attempts, client := newTestRetryClient(t, http.StatusBadGateway, http.StatusOK)
+	resp, err := client.Do(api.HciRequest{Method: api.POST, Endpoint: "instances"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", resp.StatusCode)
+	}
+	if *attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", *attempts)
+	}
+}
+
+func TestRetryMutationOnServiceUnavailable(t *testing.T) {
+	attempts, client := newTestRetryClient(t, http.StatusServiceUnavailable, http.StatusOK)
+	resp, err := client.Do(api.HciRequest{Method: api.POST, Endpoint: "instances"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if *attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", *attempts)
+	}
+}
+
+func TestNoRetryOfPermanentErrors(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	for _, test := range []struct {
+		name    string
+		url     string
+		options []api.ClientOption
+	}{
+		{name: "untrusted certificate", url: server.URL},
+		{name: "proxy rejecting the request", url: "https://api.example.invalid", options: []api.ClientOption{api.WithProxy(proxyURL)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			transport := &countingTransport{}
+			options := append(test.options, api.WithRetry(3, time.Second), api.WithTransportWrapper(func(next http.RoundTripper) http.RoundTripper {
+				transport.next = next
+				return transport
+			}))
+			client := api.NewApiClientWithOptions(test.url, "key", options...)
+			if _, err := client.Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err == nil {
+				t.Fatal("expected the request to fail")
+			}
+			if transport.requests != 1 {
+				t.Errorf("expected 1 attempt, got %d", transport.requests)
+			}
+		})
+	}
+}
+
+func TestNoRetryOfCancelledRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	client := api.NewApiClientWithOptions(server.URL, "key", api.WithRetry(3, time.Second))
+	_, err := client.Do(api.HciRequest{Method: api.GET, Endpoint: "instances", Context: ctx})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the request to be cancelled, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryReadAfterTimeout(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	client := api.NewApiClientWithOptions(server.URL, "key", api.WithRetry(3, time.Second), api.WithRequestTimeout(100*time.Millisecond))
+	if _, err := client.Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	config := Config{APIURL: server.URL, APIKey: "key", MaxConcurrentRequests: 2}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetApiClient().Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", maxInFlight)
+	}
+}
+
+func TestRequestsPerSecond(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	config := Config{APIURL: server.URL, APIKey: "key", RequestsPerSecond: 20}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	// the first 20 requests are a burst, the next 5 are spaced by 50ms
+	for i := 0; i < 25; i++ {
+		if _, err := client.GetApiClient().Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected 25 requests to take at least 200ms, took %s", elapsed)
+	}
+}
+
+func TestRequestLimitsDisabledByDefault(t *testing.T) {
+	config, diags := providerClientConfig(context.Background(), schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{"api_key": "key"}))
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if config.RequestsPerSecond != 0 || config.MaxConcurrentRequests != 0 {
+		t.Errorf("expected no request limit by default, got %v requests per second and %d concurrent requests",
+			config.RequestsPerSecond, config.MaxConcurrentRequests)
+	}
+
+	// every request waits for all the others, which only returns if none is held back by a limit
+	const requests = 8
+	var arrived sync.WaitGroup
+	arrived.Add(requests)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		arrived.Wait()
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	client := api.NewApiClientWithOptions(server.URL, "key",
+		api.WithRateLimit(1), api.WithRateLimit(0),
+		api.WithMaxConcurrentRequests(1), api.WithMaxConcurrentRequests(0),
+		api.WithRequestTimeout(5*time.Second), api.WithRetry(0, 0))
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCACertFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	request := api.HciRequest{Method: api.GET, Endpoint: "instances"}
+	config := Config{APIURL: server.URL, APIKey: "key"}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetApiClient().Do(request); err == nil {
+		t.Fatal("expected the certificate of the test server to be rejected")
+	}
+
+	config.CACertFile = filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(config.CACertFile, certificate, 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err = config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetApiClient().Do(request); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCACertPEMAndClientCertificate(t *testing.T) {
+	clientCert, clientKey := generateTestCertificate(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	request := api.HciRequest{Method: api.GET, Endpoint: "instances"}
+	config := Config{
+		APIURL:    server.URL,
+		APIKey:    "key",
+		CACertPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+	}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetApiClient().Do(request); err == nil {
+		t.Fatal("expected the request without client certificate to be rejected")
+	}
+
+	config.ClientCert = clientCert
+	config.ClientKey = filepath.Join(t.TempDir(), "client.key")
+	if err := ioutil.WriteFile(config.ClientKey, []byte(clientKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client, err = config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetApiClient().Do(request); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host == "api.example.com" {
+			atomic.AddInt32(&proxied, 1)
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer proxy.Close()
+
+	config := Config{APIURL: "http://api.example.com/api/v1", APIKey: "key", ProxyURL: proxy.URL}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetApiClient().Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err != nil {
+		t.Fatal(err)
+	}
+	if proxied != 1 {
+		t.Errorf("expected the request to go through the proxy")
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	config := Config{APIURL: server.URL, APIKey: "key", RequestTimeout: 50 * time.Millisecond}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetApiClient().Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err == nil {
+		t.Error("expected the request to time out")
+	}
+}
+
+func TestClientCertWithoutKey(t *testing.T) {
+	clientCert, _ := generateTestCertificate(t)
+	config := Config{APIURL: "https://api.example.com/api/v1", APIKey: "key", ClientCert: clientCert}
+	if _, err := config.NewClient(); err == nil {
+		t.Error("expected an error when client_key is missing")
+	}
+}
+
+// Returns a self-signed PEM certificate and its PEM private key
+func generateTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestLoggingRedactsSecrets(t *testing.T) {
+	t.Setenv("TF_LOG", "TRACE")
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"taskId":"task-1","taskStatus":"PENDING","data":{"id":"vpn-1","presharedKey":"response-secret"}}`))
+	}))
+	defer server.Close()
+	config := Config{APIURL: server.URL, APIKey: "api-key-secret"}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := api.HciRequest{
+		Method:   api.POST,
+		Endpoint: "instances",
+		Options:  map[string]string{"operation": "reset_password"},
+		Body:     []byte(`{"name":"instance","password":"request-secret","nics":[{"userData":"cloud-init-secret"}]}`),
+	}
+	if _, err := client.GetApiClient().Do(request); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := output.String()
+	for _, secret := range []string{"api-key-secret", "request-secret", "cloud-init-secret", "response-secret"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("expected %s to be redacted from the logs:\n%s", secret, logs)
+		}
+	}
+	for _, expected := range []string{"POST instances ?operation=reset_password", "status=200 task=task-1 (PENDING)", `"name":"instance"`, `"id":"vpn-1"`} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("expected %s in the logs:\n%s", expected, logs)
+		}
+	}
+}
+
+// Counts the requests sent with the transport
+// Counts the requests sent with the next transport, http.DefaultTransport by default
+type countingTransport struct {
+	next     http.RoundTripper
+	requests int32
+}
+
+func (transport *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&transport.requests, 1)
+	if transport.next == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return transport.next.RoundTrip(req)
+}
+
+func TestTransportWhateverTheOrderOfTheOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	proxyURL, _ := url.Parse("http://proxy.invalid:3128")
+
+	transport := &countingTransport{}
+	apiClient := api.NewApiClientWithOptions(server.URL, "key", api.WithTransport(transport), api.WithProxy(proxyURL),
+		api.WithInsecureSkipVerify(), api.WithRetry(0, 0))
+	if _, err := apiClient.Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err != nil {
+		t.Fatal(err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("expected the request to be sent with the transport, got %d requests", transport.requests)
+	}
+}
+
+func TestWrapTransportKeepsTheConfiguredTransport(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&proxied, 1)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer proxy.Close()
+
+	var wrapped int32
+	config := Config{
+		APIURL:   "http://api.example.com/api/v1",
+		APIKey:   "key",
+		ProxyURL: proxy.URL,
+		WrapTransport: func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				atomic.AddInt32(&wrapped, 1)
+				return next.RoundTrip(req)
+			})
+		},
+	}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetApiClient().Do(api.HciRequest{Method: api.GET, Endpoint: "instances"}); err != nil {
+		t.Fatal(err)
+	}
+	if wrapped != 1 || proxied != 1 {
+		t.Errorf("expected the request to go through the wrapper and the proxy, got %d and %d", wrapped, proxied)
+	}
+}
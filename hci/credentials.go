@@ -0,0 +1,110 @@
+package hci
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultCredentialsFile = "~/.hci/credentials"
+	defaultProfile         = "default"
+)
+
+// credentialsProfile is a named profile of a credentials file. Unset values are empty.
+type credentialsProfile struct {
+	APIURL   string
+	APIKey   string
+	Insecure *bool
+}
+
+// Reads a profile of a credentials file in INI form, e.g.
+//
+//	[default]
+//	api_key = ...
+//
+//	[staging]
+//	api_url  = https://staging.example.com/api/v1
+//	api_key  = ...
+//	insecure = true
+//
+// If the file doesn't exist and the profile wasn't chosen explicitly, an empty profile is returned.
+func readCredentialsProfile(path, profile string, explicit bool) (*credentialsProfile, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return &credentialsProfile{}, nil
+		}
+		return nil, fmt.Errorf("Error reading credentials file %s: %s", path, err)
+	}
+	defer file.Close()
+
+	sections, err := parseINI(bufio.NewScanner(file))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing credentials file %s: %s", path, err)
+	}
+	values, ok := sections[profile]
+	if !ok {
+		if !explicit {
+			return &credentialsProfile{}, nil
+		}
+		return nil, fmt.Errorf("Profile %s not found in credentials file %s", profile, path)
+	}
+
+	credentials := credentialsProfile{
+		APIURL: values["api_url"],
+		APIKey: values["api_key"],
+	}
+	if value, ok := values["insecure"]; ok {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value %q for insecure in profile %s of credentials file %s", value, profile, path)
+		}
+		credentials.Insecure = &insecure
+	}
+	return &credentials, nil
+}
+
+// Parses INI sections of key = value pairs. Lines starting with # or ; are comments.
+func parseINI(scanner *bufio.Scanner) (map[string]map[string]string, error) {
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := sections[name]; !ok {
+				sections[name] = map[string]string{}
+			}
+			section = sections[name]
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || section == nil {
+			return nil, fmt.Errorf("invalid line %d", lineNumber)
+		}
+		section[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return sections, scanner.Err()
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
@@ -0,0 +1,105 @@
+package hci
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const testCredentials = `
+# credentials of the tests
+[default]
+api_key = default-key
+
+[staging]
+api_url  = https://staging.example.com/api/v1
+api_key  = staging-key
+insecure = true
+`
+
+func writeTestCredentials(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "credentials")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCredentialsProfile(t *testing.T) {
+	path := writeTestCredentials(t, testCredentials)
+
+	profile, err := readCredentialsProfile(path, "default", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.APIKey != "default-key" || profile.APIURL != "" || profile.Insecure != nil {
+		t.Errorf("unexpected default profile: %+v", profile)
+	}
+
+	profile, err = readCredentialsProfile(path, "staging", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.APIKey != "staging-key" || profile.APIURL != "https://staging.example.com/api/v1" || profile.Insecure == nil || !*profile.Insecure {
+		t.Errorf("unexpected staging profile: %+v", profile)
+	}
+}
+
+func TestReadCredentialsProfileMissing(t *testing.T) {
+	path := writeTestCredentials(t, testCredentials)
+
+	if _, err := readCredentialsProfile(path, "production", true); err == nil {
+		t.Error("expected an error for a missing profile chosen explicitly")
+	}
+	missing := filepath.Join(t.TempDir(), "credentials")
+	if _, err := readCredentialsProfile(missing, "staging", true); err == nil {
+		t.Error("expected an error for a missing credentials file with an explicit profile")
+	}
+	profile, err := readCredentialsProfile(missing, defaultProfile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.APIKey != "" {
+		t.Errorf("expected an empty profile, got %+v", profile)
+	}
+}
+
+func TestReadCredentialsProfileInvalid(t *testing.T) {
+	for _, content := range []string{
+		"api_key = outside-of-profile\n",
+		"[default]\napi_key\n",
+		"[default]\ninsecure = maybe\n",
+	} {
+		path := writeTestCredentials(t, content)
+		if _, err := readCredentialsProfile(path, defaultProfile, false); err == nil {
+			t.Errorf("expected an error for credentials %q", content)
+		}
+	}
+}
+
+func TestProviderConfigureWithProfile(t *testing.T) {
+	path := writeTestCredentials(t, testCredentials)
+	t.Setenv(hciAPIKey, "")
+	t.Setenv("HCI_API_URL", "")
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"shared_credentials_file": path,
+		"profile":                 "staging",
+	})
+
+	provider := Provider()
+	if diags := provider.Configure(context.Background(), config); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if client := provider.Meta().(*providerMeta).client; client.GetApiURL() != "https://staging.example.com/api/v1" {
+		t.Errorf("expected the API URL of the profile, got %s", client.GetApiURL())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if diags := Provider().Configure(ctx, config); !diags.HasError() {
+		t.Error("expected configuring the provider with a cancelled context to fail")
+	}
+}
@@ -0,0 +1,132 @@
+package hci
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+// GetHciDataSourceMap return the available data source map
+func GetHciDataSourceMap() map[string]*schema.Resource {
+	return map[string]*schema.Resource{
+		"hci_compute_offering":  dataSourceHciComputeOffering(),
+		"hci_compute_offerings": dataSourceHciComputeOfferings(),
+		"hci_disk_offering":     dataSourceHciDiskOffering(),
+		"hci_disk_offerings":    dataSourceHciDiskOfferings(),
+		"hci_environment":       dataSourceHciEnvironment(),
+		"hci_instance":          dataSourceHciInstance(),
+		"hci_instances":         dataSourceHciInstances(),
+		"hci_network":           dataSourceHciNetwork(),
+		"hci_network_offering":  dataSourceHciNetworkOffering(),
+		"hci_networks":          dataSourceHciNetworks(),
+		"hci_template":          dataSourceHciTemplate(),
+		"hci_templates":         dataSourceHciTemplates(),
+		"hci_vpc":               dataSourceHciVpc(),
+		"hci_vpc_offering":      dataSourceHciVpcOffering(),
+		"hci_vpcs":              dataSourceHciVpcs(),
+		"hci_zones":             dataSourceHciZones(),
+	}
+}
+
+// mergeSchemas returns a new schema map with the attributes of all the given maps.
+// Attributes of later maps take precedence over those of earlier ones.
+func mergeSchemas(schemas ...map[string]*schema.Schema) map[string]*schema.Schema {
+	merged := map[string]*schema.Schema{}
+	for _, s := range schemas {
+		for k, v := range s {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// setAttributes sets every value of a flattened entity on the data source.
+func setAttributes(d *schema.ResourceData, attributes map[string]interface{}) error {
+	for k, v := range attributes {
+		if err := d.Set(k, v); err != nil {
+			return fmt.Errorf("Error reading Trigger: %s", err)
+		}
+	}
+	return nil
+}
+
+// getOptionalBool returns the value of an optional boolean filter and whether it was set
+// in the configuration, so that a filter explicitly set to false is not ignored.
+func getOptionalBool(d *schema.ResourceData, key string) (bool, bool) {
+	//nolint:staticcheck // there is no other way to tell an unset bool from false with this SDK version
+	v, ok := d.GetOkExists(key)
+	if !ok {
+		return false, false
+	}
+	return v.(bool), true
+}
+
+// getNameRegex compiles the name_regex filter if it is set.
+func getNameRegex(d *schema.ResourceData) (*regexp.Regexp, error) {
+	v, ok := d.GetOk("name_regex")
+	if !ok {
+		return nil, nil
+	}
+	return regexp.Compile(v.(string))
+}
+
+// getZoneIDFilter resolves the zone filter, given as a name or an ID, to a zone ID.
+func getZoneIDFilter(hciRes *hci.Resources, d *schema.ResourceData) (string, error) {
+	zone, ok := d.GetOk("zone")
+	if !ok {
+		return "", nil
+	}
+	return retrieveZoneID(hciRes, zone.(string))
+}
+
+func validateRegexp(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := regexp.Compile(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q is not a valid regular expression: %s", key, err))
+	}
+	return
+}
+
+// dataSourceListID returns a stable ID for a data source returning a list of entities.
+func dataSourceListID(environmentID string, ids []string) string {
+	return environmentID + "-" + strconv.Itoa(schema.HashString(strings.Join(ids, ",")))
+}
+
+// naturalLess compares two names by treating runs of digits as numbers, so that
+// "Ubuntu 20.04.10" sorts after "Ubuntu 20.04.9".
+func naturalLess(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		if unicode.IsDigit(ra[i]) && unicode.IsDigit(rb[j]) {
+			si := i
+			for i < len(ra) && unicode.IsDigit(ra[i]) {
+				i++
+			}
+			sj := j
+			for j < len(rb) && unicode.IsDigit(rb[j]) {
+				j++
+			}
+			na := strings.TrimLeft(string(ra[si:i]), "0")
+			nb := strings.TrimLeft(string(rb[sj:j]), "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			continue
+		}
+		ca, cb := unicode.ToLower(ra[i]), unicode.ToLower(rb[j])
+		if ca != cb {
+			return ca < cb
+		}
+		i++
+		j++
+	}
+	return len(ra)-i < len(rb)-j
+}
@@ -0,0 +1,178 @@
+package hci
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+func dataSourceHciComputeOffering() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciComputeOfferingRead,
+
+		Schema: mergeSchemas(computeOfferingAttributesSchema(), computeOfferingFilterSchema(), map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Exact name of the compute offering (case insensitive)",
+			},
+			"smallest": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If more than one compute offering matches, use the one with the fewest CPUs and the least memory",
+			},
+		}),
+	}
+}
+
+func computeOfferingFilterSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"environment_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of environment where the compute offerings are looked up",
+		},
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateRegexp,
+			Description:  "Regular expression the compute offering name must match",
+		},
+		"custom": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Whether the CPU count and memory are chosen when creating an instance",
+		},
+		"min_cpu_count": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Description: "Minimum CPU count of the compute offering. Custom compute offerings never match this filter.",
+		},
+		"min_memory_in_mb": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Description: "Minimum memory in MB of the compute offering. Custom compute offerings never match this filter.",
+		},
+	}
+}
+
+func computeOfferingAttributesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"custom": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"cpu_count": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"memory_in_mb": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+	}
+}
+
+func dataSourceHciComputeOfferingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	offerings, err := findComputeOfferings(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	if name, ok := d.GetOk("name"); ok {
+		var named []hci.ComputeOffering
+		for _, offering := range offerings {
+			if strings.EqualFold(offering.Name, name.(string)) {
+				named = append(named, offering)
+			}
+		}
+		offerings = named
+	}
+
+	if len(offerings) == 0 {
+		return diag.Errorf("Your query returned no compute offerings. Please change your search criteria and try again")
+	}
+
+	offering := offerings[0]
+	if len(offerings) > 1 {
+		if !d.Get("smallest").(bool) {
+			return diag.Errorf("Your query returned %d compute offerings. Please try a more specific search criteria, or set smallest to true", len(offerings))
+		}
+		for _, o := range offerings[1:] {
+			if o.CpuCount < offering.CpuCount || (o.CpuCount == offering.CpuCount && o.MemoryInMB < offering.MemoryInMB) {
+				offering = o
+			}
+		}
+	}
+
+	d.SetId(offering.Id)
+	return diagFromError(setAttributes(d, flattenComputeOffering(offering)))
+}
+
+// findComputeOfferings lists the compute offerings of the environment matching the filters of the data source.
+func findComputeOfferings(hciRes *hci.Resources, d *schema.ResourceData) ([]hci.ComputeOffering, error) {
+	nameRegex, err := getNameRegex(d)
+	if err != nil {
+		return nil, err
+	}
+
+	offerings, err := hciRes.ComputeOfferings.ListWithOptions(map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	custom, filterCustom := getOptionalBool(d, "custom")
+	minCPUCount, filterCPUCount := d.GetOk("min_cpu_count")
+	minMemoryInMB, filterMemory := d.GetOk("min_memory_in_mb")
+
+	var matches []hci.ComputeOffering
+	for _, offering := range offerings {
+		if nameRegex != nil && !nameRegex.MatchString(offering.Name) {
+			continue
+		}
+		if filterCustom && offering.Custom != custom {
+			continue
+		}
+		if (filterCPUCount || filterMemory) && offering.Custom {
+			continue
+		}
+		if filterCPUCount && offering.CpuCount < minCPUCount.(int) {
+			continue
+		}
+		if filterMemory && offering.MemoryInMB < minMemoryInMB.(int) {
+			continue
+		}
+		matches = append(matches, offering)
+	}
+	return matches, nil
+}
+
+func flattenComputeOffering(offering hci.ComputeOffering) map[string]interface{} {
+	return map[string]interface{}{
+		"name":         offering.Name,
+		"custom":       offering.Custom,
+		"cpu_count":    offering.CpuCount,
+		"memory_in_mb": offering.MemoryInMB,
+	}
+}
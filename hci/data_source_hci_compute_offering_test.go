@@ -0,0 +1,69 @@
+package hci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceComputeOffering(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceComputeOffering(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.hci_compute_offering.foobar", "custom", "false"),
+					resource.TestCheckResourceAttrSet("data.hci_compute_offering.foobar", "id"),
+					resource.TestCheckResourceAttrSet("data.hci_compute_offering.foobar", "cpu_count"),
+					resource.TestCheckResourceAttrSet("data.hci_compute_offering.foobar", "memory_in_mb"),
+					resource.TestCheckResourceAttr("data.hci_compute_offering.custom", "custom", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDataSourceComputeOfferings(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceComputeOfferings(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.hci_compute_offerings.foobar", "ids.0"),
+					resource.TestCheckResourceAttrSet("data.hci_compute_offerings.foobar", "compute_offerings.0.name"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceComputeOffering(environment string) string {
+	return fmt.Sprintf(`
+data "hci_compute_offering" "foobar" {
+	environment_id   = "%s"
+	min_cpu_count    = 2
+	min_memory_in_mb = 2048
+	smallest         = true
+}
+
+data "hci_compute_offering" "custom" {
+	environment_id = "%s"
+	name           = "Standard"
+}`, environment, environment)
+}
+
+func testAccDataSourceComputeOfferings(environment string) string {
+	return fmt.Sprintf(`
+data "hci_compute_offerings" "foobar" {
+	environment_id = "%s"
+}`, environment)
+}
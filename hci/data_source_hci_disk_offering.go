@@ -0,0 +1,186 @@
+package hci
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+func dataSourceHciDiskOffering() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciDiskOfferingRead,
+
+		Schema: mergeSchemas(diskOfferingAttributesSchema(), diskOfferingFilterSchema(), map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Exact name of the disk offering (case insensitive)",
+			},
+			"smallest": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If more than one disk offering matches, use the one with the fewest IOPS and the smallest size",
+			},
+		}),
+	}
+}
+
+func diskOfferingFilterSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"environment_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of environment where the disk offerings are looked up",
+		},
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateRegexp,
+			Description:  "Regular expression the disk offering name must match",
+		},
+		"custom_size": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Whether the size is chosen when creating a volume",
+		},
+		"custom_iops": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Whether the IOPS are chosen when creating a volume",
+		},
+		"required_iops": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Description: "Minimum IOPS the disk offering must be able to provide, compared against its max IOPS",
+		},
+	}
+}
+
+func diskOfferingAttributesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"gb_size": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"min_iops": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"max_iops": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"custom_size": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"custom_iops": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+	}
+}
+
+func dataSourceHciDiskOfferingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	offerings, err := findDiskOfferings(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	if name, ok := d.GetOk("name"); ok {
+		var named []hci.DiskOffering
+		for _, offering := range offerings {
+			if strings.EqualFold(offering.Name, name.(string)) {
+				named = append(named, offering)
+			}
+		}
+		offerings = named
+	}
+
+	if len(offerings) == 0 {
+		return diag.Errorf("Your query returned no disk offerings. Please change your search criteria and try again")
+	}
+
+	offering := offerings[0]
+	if len(offerings) > 1 {
+		if !d.Get("smallest").(bool) {
+			return diag.Errorf("Your query returned %d disk offerings. Please try a more specific search criteria, or set smallest to true", len(offerings))
+		}
+		for _, o := range offerings[1:] {
+			if o.MaxIops < offering.MaxIops || (o.MaxIops == offering.MaxIops && o.GbSize < offering.GbSize) {
+				offering = o
+			}
+		}
+	}
+
+	d.SetId(offering.Id)
+	return diagFromError(setAttributes(d, flattenDiskOffering(offering)))
+}
+
+// findDiskOfferings lists the disk offerings of the environment matching the filters of the data source.
+func findDiskOfferings(hciRes *hci.Resources, d *schema.ResourceData) ([]hci.DiskOffering, error) {
+	nameRegex, err := getNameRegex(d)
+	if err != nil {
+		return nil, err
+	}
+
+	offerings, err := hciRes.DiskOfferings.ListWithOptions(map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	customSize, filterCustomSize := getOptionalBool(d, "custom_size")
+	customIops, filterCustomIops := getOptionalBool(d, "custom_iops")
+	minIops, filterIops := d.GetOk("required_iops")
+
+	var matches []hci.DiskOffering
+	for _, offering := range offerings {
+		if nameRegex != nil && !nameRegex.MatchString(offering.Name) {
+			continue
+		}
+		if filterCustomSize && offering.CustomSize != customSize {
+			continue
+		}
+		if filterCustomIops && offering.CustomIops != customIops {
+			continue
+		}
+		if filterIops && offering.MaxIops < minIops.(int) {
+			continue
+		}
+		matches = append(matches, offering)
+	}
+	return matches, nil
+}
+
+func flattenDiskOffering(offering hci.DiskOffering) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        offering.Name,
+		"gb_size":     offering.GbSize,
+		"min_iops":    offering.MinIops,
+		"max_iops":    offering.MaxIops,
+		"custom_size": offering.CustomSize,
+		"custom_iops": offering.CustomIops,
+	}
+}
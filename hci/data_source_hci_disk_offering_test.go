@@ -0,0 +1,67 @@
+package hci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceDiskOffering(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceDiskOffering(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.hci_disk_offering.foobar", "id"),
+					resource.TestCheckResourceAttr("data.hci_disk_offering.foobar", "custom_size", "true"),
+					resource.TestCheckResourceAttrSet("data.hci_disk_offering.iops", "max_iops"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDataSourceDiskOfferings(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceDiskOfferings(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.hci_disk_offerings.foobar", "ids.0"),
+					resource.TestCheckResourceAttr("data.hci_disk_offerings.foobar", "disk_offerings.0.custom_size", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceDiskOffering(environment string) string {
+	return fmt.Sprintf(`
+data "hci_disk_offering" "foobar" {
+	environment_id = "%s"
+	name           = "Performance, No QoS"
+}
+
+data "hci_disk_offering" "iops" {
+	environment_id = "%s"
+	required_iops  = 500
+	smallest       = true
+}`, environment, environment)
+}
+
+func testAccDataSourceDiskOfferings(environment string) string {
+	return fmt.Sprintf(`
+data "hci_disk_offerings" "foobar" {
+	environment_id = "%s"
+	custom_size    = true
+}`, environment)
+}
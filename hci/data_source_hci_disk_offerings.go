@@ -0,0 +1,74 @@
+package hci
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciDiskOfferings() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciDiskOfferingsRead,
+
+		Schema: mergeSchemas(diskOfferingFilterSchema(), map[string]*schema.Schema{
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching disk offerings",
+			},
+			"disk_offerings": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching disk offerings",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(diskOfferingAttributesSchema(), map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func dataSourceHciDiskOfferingsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	offerings, err := findDiskOfferings(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	ids := make([]string, 0, len(offerings))
+	flattened := make([]map[string]interface{}, 0, len(offerings))
+	for _, offering := range offerings {
+		o := flattenDiskOffering(offering)
+		o["id"] = offering.Id
+		ids = append(ids, offering.Id)
+		flattened = append(flattened, o)
+	}
+
+	d.SetId(dataSourceListID(environmentID, ids))
+
+	if err := d.Set("ids", ids); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("disk_offerings", flattened); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	return nil
+}
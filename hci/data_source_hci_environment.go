@@ -0,0 +1,186 @@
+package hci
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/configuration"
+)
+
+func dataSourceHciEnvironment() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciEnvironmentRead,
+
+		Schema: map[string]*schema.Schema{
+			Name: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the environment (case insensitive)",
+			},
+			OrganizationCode: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Organization's entry point, i.e. <entry_point>.hypertec.cloud",
+				StateFunc: func(val interface{}) string {
+					return strings.ToLower(val.(string))
+				},
+			},
+			ServiceCode: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "A hypertec service code",
+			},
+			Description: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"organization_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"service_connection_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			AdminRoleUsers: {
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Usernames of the users with the Environment Admin role",
+			},
+			UserRoleUsers: {
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Usernames of the users with the User role",
+			},
+			ReadOnlyRoleUsers: {
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "Usernames of the users with the Read-only role",
+			},
+			"users": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Users of the environment",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"username": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceHciEnvironmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciClient := getClient(ctx, meta)
+	providerMeta := meta.(*providerMeta)
+
+	entryPoint := d.Get(OrganizationCode).(string)
+	if entryPoint == "" {
+		entryPoint = providerMeta.defaultOrganizationCode
+	}
+	serviceCode := d.Get(ServiceCode).(string)
+	if serviceCode == "" {
+		serviceCode = providerMeta.defaultServiceCode
+	}
+
+	options := map[string]string{}
+	if entryPoint != "" {
+		organizationID, err := getOrganizationID(hciClient, entryPoint)
+		if err != nil {
+			return diagFromError(err)
+		}
+		options["organizationId"] = organizationID
+	}
+
+	var connectionID string
+	if serviceCode != "" {
+		var err error
+		connectionID, err = getServiceConnectionID(hciClient, serviceCode)
+		if err != nil {
+			return diagFromError(err)
+		}
+		if connectionID == "" {
+			return diag.Errorf("Service connection with service code %s not found", serviceCode)
+		}
+	}
+
+	environments, err := hciClient.Environments.ListWithOptions(options)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	name := d.Get(Name).(string)
+	var matches []configuration.Environment
+	for _, environment := range environments {
+		if !strings.EqualFold(environment.Name, name) {
+			continue
+		}
+		if organizationID, ok := options["organizationId"]; ok && !strings.EqualFold(environment.Organization.Id, organizationID) {
+			continue
+		}
+		if connectionID != "" && !strings.EqualFold(environment.ServiceConnection.Id, connectionID) {
+			continue
+		}
+		matches = append(matches, environment)
+	}
+
+	if len(matches) == 0 {
+		return diag.Errorf("Environment with name %s not found", name)
+	}
+	if len(matches) > 1 {
+		return diag.Errorf("Found %d environments with name %s. Please set %s and %s to narrow the search", len(matches), name, OrganizationCode, ServiceCode)
+	}
+
+	// the list doesn't always include the roles, so read the environment itself
+	environment, err := hciClient.Environments.Get(matches[0].Id)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	d.SetId(environment.Id)
+
+	adminRoleUsers, userRoleUsers, readOnlyRoleUsers := getUsersFromRoles(environment)
+
+	users := make([]map[string]interface{}, 0, len(environment.Users))
+	for _, user := range environment.Users {
+		users = append(users, map[string]interface{}{
+			"id":       user.Id,
+			"username": user.Username,
+		})
+	}
+
+	return diagFromError(setAttributes(d, map[string]interface{}{
+		Description:             environment.Description,
+		OrganizationCode:        environment.Organization.EntryPoint,
+		ServiceCode:             environment.ServiceConnection.ServiceCode,
+		"organization_id":       environment.Organization.Id,
+		"service_connection_id": environment.ServiceConnection.Id,
+		AdminRoleUsers:          getUsernames(adminRoleUsers),
+		UserRoleUsers:           getUsernames(userRoleUsers),
+		ReadOnlyRoleUsers:       getUsernames(readOnlyRoleUsers),
+		"users":                 users,
+	}))
+}
+
+func getUsernames(users []configuration.User) []string {
+	usernames := make([]string, 0, len(users))
+	for _, user := range users {
+		usernames = append(usernames, user.Username)
+	}
+	return usernames
+}
@@ -0,0 +1,42 @@
+package hci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceEnvironment(t *testing.T) {
+	t.Parallel()
+
+	environmentName := testAccResourceName(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckEnvironmentCreateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceEnvironment(environmentName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.hci_environment.foobar", "id", "hci_environment.foobar", "id"),
+					resource.TestCheckResourceAttr("data.hci_environment.foobar", "description", fmt.Sprintf("Environment for %s workloads", environmentName)),
+					resource.TestCheckResourceAttrSet("data.hci_environment.foobar", "organization_id"),
+					resource.TestCheckResourceAttrSet("data.hci_environment.foobar", "service_connection_id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceEnvironment(name string) string {
+	return fmt.Sprintf(`
+%s
+
+data "hci_environment" "foobar" {
+	organization_code = hci_environment.foobar.organization_code
+	service_code      = hci_environment.foobar.service_code
+	name              = hci_environment.foobar.name
+}`, testAccEnvironmentCreate(name))
+}
@@ -0,0 +1,291 @@
+package hci
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+func dataSourceHciInstance() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciInstanceRead,
+
+		Schema: mergeSchemas(instanceAttributesSchema(), instanceFilterSchema(), map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Exact name of the instance (case insensitive)",
+			},
+		}),
+	}
+}
+
+func instanceFilterSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"environment_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of environment where the instances are looked up",
+		},
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateRegexp,
+			Description:  "Regular expression the instance name must match",
+		},
+		"network_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of the network of the instance",
+		},
+		"vpc_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of the VPC of the instance",
+		},
+		"state": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "State of the instance (case insensitive), e.g. Running",
+		},
+		"template": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name or ID of the template of the instance",
+		},
+	}
+}
+
+func instanceAttributesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"state": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"template_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"template_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"compute_offering_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"compute_offering_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"cpu_count": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"memory_in_mb": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"zone_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"zone_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"network_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"network_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"vpc_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"vpc_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"ip_address": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"mac_address": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"public_ips": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Public IPs forwarding traffic to the instance",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"ip_address": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+		"username": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"ssh_key_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"dedicated_group_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"affinity_group_ids": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+	}
+}
+
+func dataSourceHciInstanceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	instances, err := findInstances(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	if name, ok := d.GetOk("name"); ok {
+		var named []hci.Instance
+		for _, instance := range instances {
+			if strings.EqualFold(instance.Name, name.(string)) {
+				named = append(named, instance)
+			}
+		}
+		instances = named
+	}
+
+	if len(instances) == 0 {
+		return diag.Errorf("Your query returned no instances. Please change your search criteria and try again")
+	}
+	if len(instances) > 1 {
+		return diag.Errorf("Your query returned %d instances. Please try a more specific search criteria", len(instances))
+	}
+
+	// the list doesn't always include the public IPs, so read the instance itself
+	instance, err := hciResources.Instances.Get(instances[0].Id)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	d.SetId(instance.Id)
+	return diagFromError(setAttributes(d, flattenInstance(*instance)))
+}
+
+// findInstances lists the instances of the environment matching the filters of the data source.
+func findInstances(hciRes *hci.Resources, d *schema.ResourceData) ([]hci.Instance, error) {
+	nameRegex, err := getNameRegex(d)
+	if err != nil {
+		return nil, err
+	}
+
+	options := map[string]string{}
+	if networkID, ok := d.GetOk("network_id"); ok {
+		options["networkId"] = networkID.(string)
+	}
+	if vpcID, ok := d.GetOk("vpc_id"); ok {
+		options["vpcId"] = vpcID.(string)
+	}
+
+	instances, err := hciRes.Instances.ListWithOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	state := d.Get("state").(string)
+	template := d.Get("template").(string)
+
+	var matches []hci.Instance
+	for _, instance := range instances {
+		if nameRegex != nil && !nameRegex.MatchString(instance.Name) {
+			continue
+		}
+		// the options are only hints to the API, so filter again
+		if networkID, ok := options["networkId"]; ok && !strings.EqualFold(instance.NetworkId, networkID) {
+			continue
+		}
+		if vpcID, ok := options["vpcId"]; ok && !strings.EqualFold(instance.VpcId, vpcID) {
+			continue
+		}
+		if state != "" && !strings.EqualFold(instance.State, state) {
+			continue
+		}
+		if template != "" && !strings.EqualFold(instance.TemplateId, template) && !strings.EqualFold(instance.TemplateName, template) {
+			continue
+		}
+		matches = append(matches, instance)
+	}
+	return matches, nil
+}
+
+func flattenInstance(instance hci.Instance) map[string]interface{} {
+	publicIps := make([]map[string]interface{}, 0, len(instance.PublicIps))
+	for _, publicIP := range instance.PublicIps {
+		publicIps = append(publicIps, map[string]interface{}{
+			"id":         publicIP.Id,
+			"ip_address": publicIP.IpAddress,
+		})
+	}
+
+	return map[string]interface{}{
+		"name":                  instance.Name,
+		"state":                 instance.State,
+		"template_id":           instance.TemplateId,
+		"template_name":         instance.TemplateName,
+		"compute_offering_id":   instance.ComputeOfferingId,
+		"compute_offering_name": instance.ComputeOfferingName,
+		"cpu_count":             instance.CpuCount,
+		"memory_in_mb":          instance.MemoryInMB,
+		"zone_id":               instance.ZoneId,
+		"zone_name":             instance.ZoneName,
+		"network_id":            instance.NetworkId,
+		"network_name":          instance.NetworkName,
+		"vpc_id":                instance.VpcId,
+		"vpc_name":              instance.VpcName,
+		"ip_address":            instance.IpAddress,
+		"mac_address":           instance.MacAddress,
+		"public_ips":            publicIps,
+		"username":              instance.Username,
+		"ssh_key_name":          instance.SSHKeyName,
+		"dedicated_group_id":    instance.DedicatedGroupId,
+		"affinity_group_ids":    instance.AffinityGroupIds,
+	}
+}
@@ -0,0 +1,51 @@
+package hci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceInstance(t *testing.T) {
+	t.Parallel()
+
+	instanceName := testAccResourceName(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckInstanceCreateBasicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceInstance(environmentID, networkID, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.hci_instance.foobar", "id", "hci_instance.foobar", "id"),
+					resource.TestCheckResourceAttrPair("data.hci_instance.foobar", "ip_address", "hci_instance.foobar", "private_ip"),
+					resource.TestCheckResourceAttr("data.hci_instance.foobar", "state", "Running"),
+					resource.TestCheckResourceAttr("data.hci_instance.foobar", "cpu_count", "1"),
+					resource.TestCheckResourceAttrSet("data.hci_instance.foobar", "mac_address"),
+					resource.TestCheckResourceAttrSet("data.hci_instance.foobar", "zone_name"),
+					resource.TestCheckResourceAttr("data.hci_instances.foobar", "ids.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceInstance(environment, network, name string) string {
+	return fmt.Sprintf(`
+%s
+
+data "hci_instance" "foobar" {
+	environment_id = hci_instance.foobar.environment_id
+	network_id     = hci_instance.foobar.network_id
+	name           = hci_instance.foobar.name
+}
+
+data "hci_instances" "foobar" {
+	environment_id = hci_instance.foobar.environment_id
+	name_regex     = "^${hci_instance.foobar.name}$"
+	template       = "Ubuntu 20.04.2"
+}`, testAccInstanceCreateBasic(environment, network, name))
+}
@@ -0,0 +1,74 @@
+package hci
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciInstances() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciInstancesRead,
+
+		Schema: mergeSchemas(instanceFilterSchema(), map[string]*schema.Schema{
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching instances",
+			},
+			"instances": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching instances",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(instanceAttributesSchema(), map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func dataSourceHciInstancesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	instances, err := findInstances(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	ids := make([]string, 0, len(instances))
+	flattened := make([]map[string]interface{}, 0, len(instances))
+	for _, instance := range instances {
+		i := flattenInstance(instance)
+		i["id"] = instance.Id
+		ids = append(ids, instance.Id)
+		flattened = append(flattened, i)
+	}
+
+	d.SetId(dataSourceListID(environmentID, ids))
+
+	if err := d.Set("ids", ids); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("instances", flattened); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	return nil
+}
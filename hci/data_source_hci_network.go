@@ -0,0 +1,253 @@
+package hci
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+func dataSourceHciNetwork() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciNetworkRead,
+
+		Schema: mergeSchemas(networkAttributesSchema(), networkFilterSchema(), map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Exact name of the network (case insensitive)",
+			},
+		}),
+	}
+}
+
+func networkFilterSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"environment_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of environment where the networks are looked up",
+		},
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateRegexp,
+			Description:  "Regular expression the network name must match",
+		},
+		"vpc_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of the VPC of the network",
+		},
+		"cidr": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "CIDR of the network",
+		},
+		"zone": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name or ID of the zone of the network",
+		},
+		"state": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "State of the network (case insensitive), e.g. Implemented",
+		},
+	}
+}
+
+func networkAttributesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"description": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"vpc_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"network_offering_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"network_acl_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"network_acl_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"zone_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"zone_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"cidr": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"type": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"state": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"gateway": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"domain": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"services": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Services provided by the network and their capabilities",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"capabilities": {
+						Type:     schema.TypeMap,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceHciNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	networks, err := findNetworks(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	if name, ok := d.GetOk("name"); ok {
+		var named []hci.Network
+		for _, network := range networks {
+			if strings.EqualFold(network.Name, name.(string)) {
+				named = append(named, network)
+			}
+		}
+		networks = named
+	}
+
+	if len(networks) == 0 {
+		return diag.Errorf("Your query returned no networks. Please change your search criteria and try again")
+	}
+	if len(networks) > 1 {
+		return diag.Errorf("Your query returned %d networks. Please try a more specific search criteria", len(networks))
+	}
+
+	d.SetId(networks[0].Id)
+	return diagFromError(setAttributes(d, flattenNetwork(networks[0])))
+}
+
+// findNetworks lists the networks of the environment matching the filters of the data source.
+func findNetworks(hciRes *hci.Resources, d *schema.ResourceData) ([]hci.Network, error) {
+	nameRegex, err := getNameRegex(d)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneID, err := getZoneIDFilter(hciRes, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []hci.Network
+	if vpcID, ok := d.GetOk("vpc_id"); ok {
+		networks, err = hciRes.Networks.ListOfVpc(vpcID.(string))
+	} else {
+		networks, err = hciRes.Networks.List()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cidr := d.Get("cidr").(string)
+	state := d.Get("state").(string)
+
+	var matches []hci.Network
+	for _, network := range networks {
+		if nameRegex != nil && !nameRegex.MatchString(network.Name) {
+			continue
+		}
+		if cidr != "" && network.Cidr != cidr {
+			continue
+		}
+		if zoneID != "" && !strings.EqualFold(network.ZoneId, zoneID) {
+			continue
+		}
+		if state != "" && !strings.EqualFold(network.State, state) {
+			continue
+		}
+		matches = append(matches, network)
+	}
+	return matches, nil
+}
+
+func flattenNetwork(network hci.Network) map[string]interface{} {
+	services := make([]map[string]interface{}, 0, len(network.Services))
+	for _, service := range network.Services {
+		capabilities := make(map[string]interface{}, len(service.Capabilities))
+		for key, value := range service.Capabilities {
+			capabilities[key] = fmt.Sprint(value)
+		}
+		services = append(services, map[string]interface{}{
+			"name":         service.Name,
+			"capabilities": capabilities,
+		})
+	}
+
+	return map[string]interface{}{
+		"name":                network.Name,
+		"description":         network.Description,
+		"vpc_id":              network.VpcId,
+		"network_offering_id": network.NetworkOfferingId,
+		"network_acl_id":      network.NetworkAclId,
+		"network_acl_name":    network.NetworkAclName,
+		"zone_id":             network.ZoneId,
+		"zone_name":           network.ZoneName,
+		"cidr":                network.Cidr,
+		"type":                network.Type,
+		"state":               network.State,
+		"gateway":             network.Gateway,
+		"domain":              network.Domain,
+		"services":            services,
+	}
+}
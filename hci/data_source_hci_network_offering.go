@@ -0,0 +1,57 @@
+package hci
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciNetworkOffering() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciNetworkOfferingRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "ID of environment where the network offering is looked up",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the network offering (case insensitive)",
+			},
+		},
+	}
+}
+
+func dataSourceHciNetworkOfferingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	offerings, err := hciResources.NetworkOfferings.List()
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	name := d.Get("name").(string)
+	for _, offering := range offerings {
+		if strings.EqualFold(offering.Name, name) {
+			log.Printf("Found network offering: %+v", offering)
+			d.SetId(offering.Id)
+			return nil
+		}
+	}
+	return diag.Errorf("Network offering with name %s not found", name)
+}
@@ -0,0 +1,49 @@
+package hci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceNetwork(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceNetwork(environmentID, vpcID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.hci_network.foobar", "id", networkID),
+					resource.TestCheckResourceAttr("data.hci_network.foobar", "vpc_id", vpcID),
+					resource.TestCheckResourceAttrSet("data.hci_network.foobar", "gateway"),
+					resource.TestCheckResourceAttrSet("data.hci_network.foobar", "network_acl_id"),
+					resource.TestCheckResourceAttrSet("data.hci_networks.foobar", "networks.0.services.#"),
+					resource.TestCheckResourceAttrSet("data.hci_network_offering.foobar", "id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceNetwork(environment, vpc string) string {
+	return fmt.Sprintf(`
+data "hci_networks" "foobar" {
+	environment_id = "%s"
+	vpc_id         = "%s"
+}
+
+data "hci_network" "foobar" {
+	environment_id = "%s"
+	vpc_id         = "%s"
+	name           = [for n in data.hci_networks.foobar.networks : n.name if n.id == "%s"][0]
+}
+
+data "hci_network_offering" "foobar" {
+	environment_id = "%s"
+	name           = "DefaultIsolatedNetworkOfferingForVpcNetworks"
+}`, environment, vpc, environment, vpc, networkID, environment)
+}
@@ -0,0 +1,74 @@
+package hci
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciNetworks() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciNetworksRead,
+
+		Schema: mergeSchemas(networkFilterSchema(), map[string]*schema.Schema{
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching networks",
+			},
+			"networks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching networks",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(networkAttributesSchema(), map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func dataSourceHciNetworksRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	networks, err := findNetworks(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	ids := make([]string, 0, len(networks))
+	flattened := make([]map[string]interface{}, 0, len(networks))
+	for _, network := range networks {
+		n := flattenNetwork(network)
+		n["id"] = network.Id
+		ids = append(ids, network.Id)
+		flattened = append(flattened, n)
+	}
+
+	d.SetId(dataSourceListID(environmentID, ids))
+
+	if err := d.Set("ids", ids); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("networks", flattened); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	return nil
+}
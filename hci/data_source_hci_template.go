@@ -0,0 +1,291 @@
+package hci
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+func dataSourceHciTemplate() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciTemplateRead,
+
+		Schema: mergeSchemas(templateAttributesSchema(), templateFilterSchema(), map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Exact name of the template (case insensitive)",
+			},
+			"most_recent": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If more than one template matches, use the one with the highest version in its name",
+			},
+		}),
+	}
+}
+
+func templateFilterSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"environment_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of environment where the templates are looked up",
+		},
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateRegexp,
+			Description:  "Regular expression the template name must match",
+		},
+		"os_type": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "OS type of the template (e.g. Ubuntu 20.04 (64-bit))",
+		},
+		"hypervisor": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "Hypervisor of the template",
+		},
+		"ready": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Whether the template is ready to be used",
+		},
+		"resizable": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Whether the root volume size of instances created from the template can be chosen",
+		},
+		"ssh_key_enabled": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Whether an SSH key can be attached to instances created from the template",
+		},
+		"zone": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name or ID of a zone in which the template must be available",
+		},
+	}
+}
+
+func templateAttributesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"description": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"size": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"available_publicly": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"ready": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"ssh_key_enabled": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"password_enabled": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"extractable": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"resizable": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"os_type": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"os_type_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"hypervisor": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"format": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"project_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"url": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"zone_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"available_in_zones": {
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+	}
+}
+
+func dataSourceHciTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	templates, err := findTemplates(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	if name, ok := d.GetOk("name"); ok {
+		var named []hci.Template
+		for _, template := range templates {
+			if strings.EqualFold(template.Name, name.(string)) {
+				named = append(named, template)
+			}
+		}
+		templates = named
+	}
+
+	if len(templates) == 0 {
+		return diag.Errorf("Your query returned no templates. Please change your search criteria and try again")
+	}
+
+	template := templates[0]
+	if len(templates) > 1 {
+		if !d.Get("most_recent").(bool) {
+			return diag.Errorf("Your query returned %d templates. Please try a more specific search criteria, or set most_recent to true", len(templates))
+		}
+		for _, t := range templates[1:] {
+			if naturalLess(template.Name, t.Name) {
+				template = t
+			}
+		}
+	}
+
+	d.SetId(template.ID)
+	return diagFromError(setAttributes(d, flattenTemplate(template)))
+}
+
+// findTemplates lists the templates of the environment matching the filters of the data source.
+func findTemplates(hciRes *hci.Resources, d *schema.ResourceData) ([]hci.Template, error) {
+	nameRegex, err := getNameRegex(d)
+	if err != nil {
+		return nil, err
+	}
+
+	options := map[string]string{}
+	if osType, ok := d.GetOk("os_type"); ok {
+		options["osType"] = osType.(string)
+	}
+	if hypervisor, ok := d.GetOk("hypervisor"); ok {
+		options["hypervisor"] = hypervisor.(string)
+	}
+
+	zoneID, err := getZoneIDFilter(hciRes, d)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := hciRes.Templates.ListWithOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	ready, filterReady := getOptionalBool(d, "ready")
+	resizable, filterResizable := getOptionalBool(d, "resizable")
+	sshKeyEnabled, filterSSHKeyEnabled := getOptionalBool(d, "ssh_key_enabled")
+
+	var matches []hci.Template
+	for _, template := range templates {
+		if nameRegex != nil && !nameRegex.MatchString(template.Name) {
+			continue
+		}
+		if osType, ok := options["osType"]; ok && !strings.EqualFold(template.OSType, osType) {
+			continue
+		}
+		if hypervisor, ok := options["hypervisor"]; ok && !strings.EqualFold(template.Hypervisor, hypervisor) {
+			continue
+		}
+		if filterReady && template.Ready != ready {
+			continue
+		}
+		if filterResizable && template.Resizable != resizable {
+			continue
+		}
+		if filterSSHKeyEnabled && template.SSHKeyEnabled != sshKeyEnabled {
+			continue
+		}
+		if zoneID != "" && !templateAvailableInZone(template, zoneID) {
+			continue
+		}
+		matches = append(matches, template)
+	}
+	return matches, nil
+}
+
+func templateAvailableInZone(template hci.Template, zoneID string) bool {
+	for _, id := range template.AvailableInZones {
+		if strings.EqualFold(id, zoneID) {
+			return true
+		}
+	}
+	return strings.EqualFold(template.ZoneID, zoneID)
+}
+
+func flattenTemplate(template hci.Template) map[string]interface{} {
+	return map[string]interface{}{
+		"name":               template.Name,
+		"description":        template.Description,
+		"size":               template.Size,
+		"available_publicly": template.AvailablePublicly,
+		"ready":              template.Ready,
+		"ssh_key_enabled":    template.SSHKeyEnabled,
+		"password_enabled":   template.PassowordEnabled,
+		"extractable":        template.Extractable,
+		"resizable":          template.Resizable,
+		"os_type":            template.OSType,
+		"os_type_id":         template.OSTypeID,
+		"hypervisor":         template.Hypervisor,
+		"format":             template.Format,
+		"project_id":         template.ProjectID,
+		"url":                template.URL,
+		"zone_id":            template.ZoneID,
+		"available_in_zones": template.AvailableInZones,
+	}
+}
@@ -0,0 +1,83 @@
+package hci
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceTemplate(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceTemplate(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("data.hci_template.foobar", "name", regexp.MustCompile(`^Ubuntu 20\.04`)),
+					resource.TestCheckResourceAttr("data.hci_template.foobar", "ready", "true"),
+					resource.TestCheckResourceAttrSet("data.hci_template.foobar", "id"),
+					resource.TestCheckResourceAttrSet("data.hci_template.foobar", "os_type"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDataSourceTemplates(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceTemplates(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.hci_templates.foobar", "ids.0"),
+					resource.TestMatchResourceAttr("data.hci_templates.foobar", "templates.0.name", regexp.MustCompile(`^Ubuntu`)),
+				),
+			},
+		},
+	})
+}
+
+func TestNaturalLess(t *testing.T) {
+	cases := []struct {
+		a, b string
+		less bool
+	}{
+		{"Ubuntu 20.04.2", "Ubuntu 20.04.10", true},
+		{"Ubuntu 20.04.10", "Ubuntu 20.04.2", false},
+		{"Ubuntu 18.04", "ubuntu 20.04", true},
+		{"Ubuntu 20.04", "Ubuntu 20.04.1", true},
+		{"CentOS 7", "CentOS 7", false},
+	}
+	for _, c := range cases {
+		if got := naturalLess(c.a, c.b); got != c.less {
+			t.Errorf("naturalLess(%q, %q) = %t, want %t", c.a, c.b, got, c.less)
+		}
+	}
+}
+
+func testAccDataSourceTemplate(environment string) string {
+	return fmt.Sprintf(`
+data "hci_template" "foobar" {
+	environment_id = "%s"
+	name_regex     = "^Ubuntu 20\\.04"
+	ready          = true
+	most_recent    = true
+}`, environment)
+}
+
+func testAccDataSourceTemplates(environment string) string {
+	return fmt.Sprintf(`
+data "hci_templates" "foobar" {
+	environment_id = "%s"
+	name_regex     = "^Ubuntu"
+}`, environment)
+}
@@ -0,0 +1,74 @@
+package hci
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciTemplates() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciTemplatesRead,
+
+		Schema: mergeSchemas(templateFilterSchema(), map[string]*schema.Schema{
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching templates",
+			},
+			"templates": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching templates",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(templateAttributesSchema(), map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func dataSourceHciTemplatesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	templates, err := findTemplates(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	ids := make([]string, 0, len(templates))
+	flattened := make([]map[string]interface{}, 0, len(templates))
+	for _, template := range templates {
+		t := flattenTemplate(template)
+		t["id"] = template.ID
+		ids = append(ids, template.ID)
+		flattened = append(flattened, t)
+	}
+
+	d.SetId(dataSourceListID(environmentID, ids))
+
+	if err := d.Set("ids", ids); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("templates", flattened); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package hci
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+func dataSourceHciVpc() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciVpcRead,
+
+		Schema: mergeSchemas(vpcAttributesSchema(), vpcFilterSchema(), map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Exact name of the VPC (case insensitive)",
+			},
+		}),
+	}
+}
+
+func vpcFilterSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"environment_id": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "ID of environment where the VPCs are looked up",
+		},
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validateRegexp,
+			Description:  "Regular expression the VPC name must match",
+		},
+		"cidr": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "CIDR of the VPC",
+		},
+		"zone": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name or ID of the zone of the VPC",
+		},
+		"state": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "State of the VPC (case insensitive), e.g. Enabled",
+		},
+	}
+}
+
+func vpcAttributesSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"description": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"vpc_offering_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"state": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"cidr": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"zone_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"zone_name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"network_domain": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"source_nat_ip": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"vpn_status": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"type": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+}
+
+func dataSourceHciVpcRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	vpcs, err := findVpcs(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	if name, ok := d.GetOk("name"); ok {
+		var named []hci.Vpc
+		for _, vpc := range vpcs {
+			if strings.EqualFold(vpc.Name, name.(string)) {
+				named = append(named, vpc)
+			}
+		}
+		vpcs = named
+	}
+
+	if len(vpcs) == 0 {
+		return diag.Errorf("Your query returned no VPCs. Please change your search criteria and try again")
+	}
+	if len(vpcs) > 1 {
+		return diag.Errorf("Your query returned %d VPCs. Please try a more specific search criteria", len(vpcs))
+	}
+
+	d.SetId(vpcs[0].Id)
+	return diagFromError(setAttributes(d, flattenVpc(vpcs[0])))
+}
+
+// findVpcs lists the VPCs of the environment matching the filters of the data source.
+func findVpcs(hciRes *hci.Resources, d *schema.ResourceData) ([]hci.Vpc, error) {
+	nameRegex, err := getNameRegex(d)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneID, err := getZoneIDFilter(hciRes, d)
+	if err != nil {
+		return nil, err
+	}
+
+	vpcs, err := hciRes.Vpcs.ListWithOptions(map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	cidr := d.Get("cidr").(string)
+	state := d.Get("state").(string)
+
+	var matches []hci.Vpc
+	for _, vpc := range vpcs {
+		if nameRegex != nil && !nameRegex.MatchString(vpc.Name) {
+			continue
+		}
+		if cidr != "" && vpc.Cidr != cidr {
+			continue
+		}
+		if zoneID != "" && !strings.EqualFold(vpc.ZoneId, zoneID) {
+			continue
+		}
+		if state != "" && !strings.EqualFold(vpc.State, state) {
+			continue
+		}
+		matches = append(matches, vpc)
+	}
+	return matches, nil
+}
+
+func flattenVpc(vpc hci.Vpc) map[string]interface{} {
+	return map[string]interface{}{
+		"name":            vpc.Name,
+		"description":     vpc.Description,
+		"vpc_offering_id": vpc.VpcOfferingId,
+		"state":           vpc.State,
+		"cidr":            vpc.Cidr,
+		"zone_id":         vpc.ZoneId,
+		"zone_name":       vpc.ZoneName,
+		"network_domain":  vpc.NetworkDomain,
+		"source_nat_ip":   vpc.SourceNatIp,
+		"vpn_status":      vpc.VpnStatus,
+		"type":            vpc.Type,
+	}
+}
@@ -0,0 +1,63 @@
+package hci
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciVpcOffering() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciVpcOfferingRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "ID of environment where the VPC offering is looked up",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the VPC offering (case insensitive)",
+			},
+			"state": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceHciVpcOfferingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	offerings, err := hciResources.VpcOfferings.List()
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	name := d.Get("name").(string)
+	for _, offering := range offerings {
+		if strings.EqualFold(offering.Name, name) {
+			log.Printf("Found vpc offering: %+v", offering)
+			d.SetId(offering.Id)
+			return diagFromError(setAttributes(d, map[string]interface{}{
+				"state": offering.State,
+			}))
+		}
+	}
+	return diag.Errorf("VPC offering with name %s not found", name)
+}
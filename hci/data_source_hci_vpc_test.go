@@ -0,0 +1,48 @@
+package hci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceVpc(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVpc(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.hci_vpc.foobar", "id", "data.hci_vpcs.foobar", "ids.0"),
+					resource.TestCheckResourceAttrSet("data.hci_vpc.foobar", "name"),
+					resource.TestCheckResourceAttrSet("data.hci_vpc.foobar", "cidr"),
+					resource.TestCheckResourceAttrSet("data.hci_vpc.foobar", "source_nat_ip"),
+					resource.TestCheckResourceAttrSet("data.hci_vpc_offering.foobar", "id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceVpc(environment string) string {
+	return fmt.Sprintf(`
+data "hci_vpcs" "foobar" {
+	environment_id = "%s"
+	state          = "Enabled"
+}
+
+data "hci_vpc" "foobar" {
+	environment_id = "%s"
+	name           = data.hci_vpcs.foobar.vpcs[0].name
+	cidr           = data.hci_vpcs.foobar.vpcs[0].cidr
+}
+
+data "hci_vpc_offering" "foobar" {
+	environment_id = "%s"
+	name           = "Default VPC offering"
+}`, environment, environment, environment)
+}
@@ -0,0 +1,74 @@
+package hci
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciVpcs() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciVpcsRead,
+
+		Schema: mergeSchemas(vpcFilterSchema(), map[string]*schema.Schema{
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching VPCs",
+			},
+			"vpcs": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching VPCs",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(vpcAttributesSchema(), map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func dataSourceHciVpcsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	vpcs, err := findVpcs(&hciResources, d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	ids := make([]string, 0, len(vpcs))
+	flattened := make([]map[string]interface{}, 0, len(vpcs))
+	for _, vpc := range vpcs {
+		v := flattenVpc(vpc)
+		v["id"] = vpc.Id
+		ids = append(ids, vpc.Id)
+		flattened = append(flattened, v)
+	}
+
+	d.SetId(dataSourceListID(environmentID, ids))
+
+	if err := d.Set("ids", ids); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("vpcs", flattened); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package hci
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHciZones() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceHciZonesRead,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "ID of environment where the zones are looked up",
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateRegexp,
+				Description:  "Regular expression the zone name must match",
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "IDs of the matching zones",
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Names of the matching zones, in the same order as the IDs",
+			},
+		},
+	}
+}
+
+func dataSourceHciZonesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	environmentID, eerr := getEnvironmentID(d, meta)
+	if eerr != nil {
+		return diagFromError(eerr)
+	}
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, environmentID)
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	nameRegex, err := getNameRegex(d)
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	zones, err := hciResources.Zones.List()
+	if err != nil {
+		return diagFromError(err)
+	}
+
+	ids := make([]string, 0, len(zones))
+	names := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		if nameRegex != nil && !nameRegex.MatchString(zone.Name) {
+			continue
+		}
+		ids = append(ids, zone.Id)
+		names = append(names, zone.Name)
+	}
+
+	d.SetId(dataSourceListID(environmentID, ids))
+
+	if err := d.Set("ids", ids); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("names", names); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package hci
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceZones(t *testing.T) {
+	t.Parallel()
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceZones(environmentID),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.hci_zones.foobar", "ids.0"),
+					resource.TestCheckResourceAttrSet("data.hci_zones.foobar", "names.0"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceZones(environment string) string {
+	return fmt.Sprintf(`
+data "hci_zones" "foobar" {
+	environment_id = "%s"
+}`, environment)
+}
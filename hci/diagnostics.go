@@ -0,0 +1,137 @@
+package hci
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hypertec-cloud/go-hci/api"
+	"github.com/hypertec-cloud/go-hci/services"
+)
+
+// Keys of the context of an HciError naming the field the error is about
+var errorContextFieldKeys = []string{"field", "fieldName", "parameter"}
+
+// API fields whose attribute isn't the snake case of their name
+var errorContextAttributes = map[string]string{
+	"computeOfferingId": "compute_offering",
+	"diskOfferingId":    "disk_offering",
+	"networkOfferingId": "network_offering",
+	"vpcOfferingId":     "vpc_offering",
+	"templateId":        "template",
+	"zoneId":            "zone",
+	"gbSize":            "size_in_gb",
+}
+
+// Converts an error to diagnostics. See diagFromErrorf
+func diagFromError(err error) diag.Diagnostics {
+	return diagFromErrorf(err, "")
+}
+
+// Converts an error to diagnostics, prefixing their summary with the formatted message if it isn't empty.
+// Every error of an api.HciErrorResponse becomes a diagnostic with the error code as summary, the message as
+// detail and the attribute the error is about as path. So does every reason of the failure of a failed task.
+func diagFromErrorf(err error, format string, args ...interface{}) diag.Diagnostics {
+	if err == nil {
+		return nil
+	}
+	prefix := ""
+	if format != "" {
+		prefix = fmt.Sprintf(format, args...) + ": "
+	}
+
+	var errorResponse api.HciErrorResponse
+	if errors.As(err, &errorResponse) && len(errorResponse.Errors) > 0 {
+		prefix += wrappingMessage(err, errorResponse)
+		var diags diag.Diagnostics
+		for _, hciError := range errorResponse.Errors {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       prefix + errorSummary(hciError.ErrorCode, errorResponse.StatusCode),
+				Detail:        hciError.Message,
+				AttributePath: errorAttributePath(hciError.Context),
+			})
+		}
+		return diags
+	}
+
+	var failedTask services.FailedTask
+	if errors.As(err, &failedTask) {
+		prefix += wrappingMessage(err, failedTask) + "Task id=" + failedTask.Id + " failed"
+		if len(failedTask.Errors) == 0 {
+			return diag.Diagnostics{{
+				Severity: diag.Error,
+				Summary:  prefix,
+				Detail:   strings.TrimSpace(string(failedTask.Result)),
+			}}
+		}
+		var diags diag.Diagnostics
+		for _, hciError := range failedTask.Errors {
+			summary := prefix
+			if hciError.ErrorCode != "" {
+				summary += ": " + hciError.ErrorCode
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       summary,
+				Detail:        hciError.Message,
+				AttributePath: errorAttributePath(hciError.Context),
+			})
+		}
+		return diags
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  prefix + err.Error(),
+	}}
+}
+
+// Returns the message an error wraps the cause with, e.g. "Error starting instance: " for an error created
+// with fmt.Errorf("Error starting instance: %w", cause)
+func wrappingMessage(err error, cause error) string {
+	message := err.Error()
+	i := strings.LastIndex(message, cause.Error())
+	if i <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimSpace(message[:i]), ":") + ": "
+}
+
+func errorSummary(errorCode string, statusCode int) string {
+	if errorCode == "" {
+		return fmt.Sprintf("HTTP status code %d", statusCode)
+	}
+	return fmt.Sprintf("%s (HTTP status code %d)", errorCode, statusCode)
+}
+
+// Returns the path of the attribute named in the context of an HciError, or nil if there is none
+func errorAttributePath(context map[string]interface{}) cty.Path {
+	for _, key := range errorContextFieldKeys {
+		if field, ok := context[key].(string); ok && field != "" {
+			if attribute, ok := errorContextAttributes[field]; ok {
+				return cty.GetAttrPath(attribute)
+			}
+			return cty.GetAttrPath(toSnakeCase(field))
+		}
+	}
+	return nil
+}
+
+// Converts an API field name such as networkId to the name of its attribute, network_id
+func toSnakeCase(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				builder.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
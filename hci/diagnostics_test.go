@@ -0,0 +1,91 @@
+package hci
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hypertec-cloud/go-hci/api"
+	"github.com/hypertec-cloud/go-hci/services"
+)
+
+func TestDiagFromErrorResponse(t *testing.T) {
+	err := api.HciErrorResponse{
+		StatusCode: 400,
+		Errors: []api.HciError{
+			{ErrorCode: "FIELD_ERROR", Message: "The compute offering is not available", Context: map[string]interface{}{"field": "computeOfferingId"}},
+			{ErrorCode: "FIELD_ERROR", Message: "The network is full", Context: map[string]interface{}{"field": "networkId"}},
+			{ErrorCode: "BAD_REQUEST", Message: "Invalid request"},
+		},
+	}
+	diags := diagFromErrorf(fmt.Errorf("Error starting instance foo: %w", err), "Error creating the new instance %s", "foo")
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %d", len(diags))
+	}
+	expected := []diag.Diagnostic{
+		{
+			Severity:      diag.Error,
+			Summary:       "Error creating the new instance foo: Error starting instance foo: FIELD_ERROR (HTTP status code 400)",
+			Detail:        "The compute offering is not available",
+			AttributePath: cty.GetAttrPath("compute_offering"),
+		},
+		{
+			Severity:      diag.Error,
+			Summary:       "Error creating the new instance foo: Error starting instance foo: FIELD_ERROR (HTTP status code 400)",
+			Detail:        "The network is full",
+			AttributePath: cty.GetAttrPath("network_id"),
+		},
+		{
+			Severity: diag.Error,
+			Summary:  "Error creating the new instance foo: Error starting instance foo: BAD_REQUEST (HTTP status code 400)",
+			Detail:   "Invalid request",
+		},
+	}
+	for i, d := range diags {
+		if d.Severity != expected[i].Severity || d.Summary != expected[i].Summary || d.Detail != expected[i].Detail || !d.AttributePath.Equals(expected[i].AttributePath) {
+			t.Errorf("expected diagnostic %+v, got %+v", expected[i], d)
+		}
+	}
+}
+
+func TestDiagFromFailedTask(t *testing.T) {
+	err := services.FailedTask{
+		Id:     "task-1",
+		Status: services.FAILED,
+		Errors: []api.HciError{{ErrorCode: "INSUFFICIENT_CAPACITY", Message: "No host can run the instance", Context: map[string]interface{}{"field": "computeOfferingId"}}},
+	}
+	diags := diagFromErrorf(err, "Error creating the new instance %s", "foo")
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(diags))
+	}
+	if diags[0].Summary != "Error creating the new instance foo: Task id=task-1 failed: INSUFFICIENT_CAPACITY" ||
+		diags[0].Detail != "No host can run the instance" || !diags[0].AttributePath.Equals(cty.GetAttrPath("compute_offering")) {
+		t.Errorf("unexpected diagnostic %+v", diags[0])
+	}
+
+	err = services.FailedTask{Id: "task-2", Status: services.FAILED, Result: []byte(`{"unexpected":true}`)}
+	diags = diagFromError(err)
+	if len(diags) != 1 || diags[0].Summary != "Task id=task-2 failed" || diags[0].Detail != `{"unexpected":true}` {
+		t.Errorf("unexpected diagnostics %+v", diags)
+	}
+}
+
+func TestDiagFromOtherError(t *testing.T) {
+	if diags := diagFromError(nil); diags != nil {
+		t.Errorf("expected no diagnostics, got %+v", diags)
+	}
+	diags := diagFromErrorf(errors.New("boom"), "Error enabling the VPN")
+	if len(diags) != 1 || diags[0].Summary != "Error enabling the VPN: boom" {
+		t.Errorf("unexpected diagnostics %+v", diags)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{"networkId": "network_id", "name": "name", "privateIpId": "private_ip_id"} {
+		if actual := toSnakeCase(name); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+}
@@ -0,0 +1,1216 @@
+package hci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/hypertec-cloud/go-hci/api"
+	"github.com/hypertec-cloud/go-hci/services"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+// Names of the organization, service connection, environment and catalogues of a fake API
+const (
+	fakeAPIKey                  = "fake-api-key"
+	fakeOrganizationCode        = "test"
+	fakeServiceCode             = "compute-qc"
+	fakeEnvironmentName         = "test-env"
+	fakeUsername                = "alice"
+	fakeZone                    = "QC-1"
+	fakeOtherZone               = "QC-2"
+	fakeTemplate                = "Ubuntu 20.04"
+	fakeComputeOffering         = "1vCPU.1GB"
+	fakeOtherComputeOffering    = "2vCPU.4GB"
+	fakeCustomComputeOffering   = "Custom"
+	fakeDiskOffering            = "20GB - 20 IOPS Min."
+	fakeCustomDiskOffering      = "Custom Disk"
+	fakeVpcOffering             = "Default VPC offering"
+	fakeNetworkOffering         = "Standard Network"
+	fakeLoadBalancedNetworkOffr = "Load Balanced Network"
+)
+
+type fakeEntity map[string]interface{}
+
+// fakeAPI is an in-process HCI API keeping its entities in memory, to test the resources without a cloud.
+// It implements the entities of the services at /services/{code}/{environment}/{entity}, their operations,
+// the environments, organizations, service connections and users, and the tasks of asynchronous requests.
+// Creations, updates, deletions and operations are applied right away. Their task is pending when the
+// request is answered, and completes once it has been polled pendingPolls more times.
+type fakeAPI struct {
+	server *httptest.Server
+
+	mu sync.Mutex
+	// Entities by id, by entity type, by service code and environment name, e.g. compute-qc/test-env
+	entities           map[string]map[string]map[string]fakeEntity
+	environments       map[string]fakeEntity
+	organizations      []fakeEntity
+	serviceConnections []fakeEntity
+	users              []fakeEntity
+	tasks              map[string]*fakeTask
+	pendingPolls       int
+	// Error codes of the requests whose task fails, by entity type and operation, e.g. instances/start
+	failures map[string]string
+	// Entity type and operation of every operation executed, e.g. instances/stop
+	operations []string
+	lastID     int
+	lastIP     int
+
+	environmentID string
+	userID        string
+}
+
+type fakeTask struct {
+	id     string
+	status string
+	result interface{}
+	polls  int
+}
+
+// An error response of the fake API
+type fakeError struct {
+	status  int
+	code    string
+	message string
+	field   string
+}
+
+func (err fakeError) Error() string {
+	return err.message
+}
+
+func notFound(entityType, id string) fakeError {
+	return fakeError{http.StatusNotFound, "NOT_FOUND", fmt.Sprintf("%s %s not found", entityType, id), ""}
+}
+
+func badRequest(field, message string) fakeError {
+	return fakeError{http.StatusBadRequest, "FIELD_ERROR", message, field}
+}
+
+// Starts a fake API with an organization, a service connection, a user and an environment with its catalogues.
+// The API is stopped at the end of the test.
+func newFakeAPI(t *testing.T) *fakeAPI {
+	fake := &fakeAPI{
+		entities:     map[string]map[string]map[string]fakeEntity{},
+		environments: map[string]fakeEntity{},
+		tasks:        map[string]*fakeTask{},
+		failures:     map[string]string{},
+	}
+	organization := fakeEntity{"id": fake.newID(), "name": "Test", "entryPoint": fakeOrganizationCode}
+	fake.organizations = []fakeEntity{organization}
+	fake.serviceConnections = []fakeEntity{{"id": fake.newID(), "name": "Compute QC", "serviceCode": fakeServiceCode}}
+	fake.userID = fake.newID()
+	fake.users = []fakeEntity{{"id": fake.userID, "username": fakeUsername, "organization": organization}}
+
+	environment, err := fake.createEnvironment(fakeEntity{
+		"name":              fakeEnvironmentName,
+		"description":       "Environment of the fake API",
+		"organization":      map[string]interface{}{"id": organization["id"]},
+		"serviceConnection": map[string]interface{}{"id": fake.serviceConnections[0]["id"]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.environmentID = environment["id"].(string)
+
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	t.Cleanup(fake.server.Close)
+	return fake
+}
+
+// Returns the provider block configuring the provider with the fake API, followed by the config
+func (fake *fakeAPI) config(config string) string {
+	return fmt.Sprintf(`
+provider "hci" {
+	api_url                = "%s"
+	api_key                = "%s"
+	default_environment_id = "%s"
+	requests_per_second    = 0
+	max_retries            = 0
+}
+%s`, fake.server.URL, fakeAPIKey, fake.environmentID, config)
+}
+
+// Config of a VPC, hci_vpc.fake, with a network, hci_network.fake
+const fakeNetworkConfig = `
+resource "hci_vpc" "fake" {
+	name         = "fake"
+	description  = "VPC of the fake API"
+	vpc_offering = "Default VPC offering"
+}
+
+resource "hci_network" "fake" {
+	name             = "fake"
+	description      = "Network of the fake API"
+	vpc_id           = hci_vpc.fake.id
+	network_offering = "Standard Network"
+	network_acl      = "default_allow"
+}
+`
+
+// Config of an instance, hci_instance.fake, in the network of fakeNetworkConfig
+const fakeInstanceConfig = fakeNetworkConfig + `
+resource "hci_instance" "fake" {
+	name             = "fake"
+	template         = "Ubuntu 20.04"
+	compute_offering = "1vCPU.1GB"
+	network_id       = hci_network.fake.id
+}
+`
+
+// Returns a fresh provider every time Terraform starts one. The providers are configured by the config.
+func (fake *fakeAPI) providerFactories() map[string]func() (*schema.Provider, error) {
+	return map[string]func() (*schema.Provider, error){
+		"hci": func() (*schema.Provider, error) {
+			return Provider(), nil
+		},
+	}
+}
+
+// Returns a CheckDestroy verifying that the entities of the resources of the type were deleted
+func (fake *fakeAPI) checkDestroy(resourceType, entityType string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != resourceType {
+				continue
+			}
+			if entity := fake.get(entityType, rs.Primary.ID); entity != nil {
+				return fmt.Errorf("%s %s still exists: %v", entityType, rs.Primary.ID, entity)
+			}
+		}
+		return nil
+	}
+}
+
+// Returns a TestCheckFunc verifying that the entity of the resource has the value for the API field
+func (fake *fakeAPI) checkEntity(name, entityType, field string, value interface{}) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+		entity := fake.get(entityType, rs.Primary.ID)
+		if entity == nil {
+			return fmt.Errorf("%s %s not found", entityType, rs.Primary.ID)
+		}
+		if fmt.Sprint(entity[field]) != fmt.Sprint(value) {
+			return fmt.Errorf("expected %s of %s %s to be %v, got %v", field, entityType, rs.Primary.ID, value, entity[field])
+		}
+		return nil
+	}
+}
+
+// Returns a copy of the entity of the fake environment, or of the environment if the type is environments.
+// Returns nil if it doesn't exist.
+func (fake *fakeAPI) get(entityType, id string) fakeEntity {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	entity := fake.environments[id]
+	if entityType != "environments" {
+		entity = fake.find(fake.environmentKey(fake.environments[fake.environmentID]), entityType, id)
+	}
+	if entity == nil {
+		return nil
+	}
+	return copyEntity(entity)
+}
+
+// Returns copies of the entities of the type of the fake environment
+func (fake *fakeAPI) all(entityType string) []fakeEntity {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	entities := []fakeEntity{}
+	for _, entity := range fake.entities[fake.environmentKey(fake.environments[fake.environmentID])][entityType] {
+		entities = append(entities, copyEntity(entity))
+	}
+	return entities
+}
+
+// Returns the id of the entity of the catalogue, or of the fake environment, with the name
+func (fake *fakeAPI) idOf(entityType, name string) string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for id, entity := range fake.entities[fake.environmentKey(fake.environments[fake.environmentID])][entityType] {
+		if strings.EqualFold(str(entity, "name"), name) {
+			return id
+		}
+	}
+	return ""
+}
+
+// Adds an entity to the fake environment, returning its id
+func (fake *fakeAPI) add(entityType string, entity fakeEntity) string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	environment := fake.environmentKey(fake.environments[fake.environmentID])
+	if str(entity, "id") == "" {
+		entity["id"] = fake.newID()
+	}
+	fake.collection(environment, entityType)[str(entity, "id")] = entity
+	return str(entity, "id")
+}
+
+// Makes the task of the operation of the entity type fail, e.g. fail("instances", "start", "START_FAILED")
+func (fake *fakeAPI) fail(entityType, operation, errorCode string) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.failures[entityType+"/"+operation] = errorCode
+}
+
+// Returns whether the operation of the entity type was executed, e.g. executed("instances", "stop")
+func (fake *fakeAPI) executed(entityType, operation string) bool {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for _, executed := range fake.operations {
+		if executed == entityType+"/"+operation {
+			return true
+		}
+	}
+	return false
+}
+
+func (fake *fakeAPI) newID() string {
+	fake.lastID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012x", fake.lastID)
+}
+
+func (fake *fakeAPI) newIP(prefix string) string {
+	fake.lastIP++
+	return fmt.Sprintf("%s.%d.%d", prefix, fake.lastIP/250, fake.lastIP%250+2)
+}
+
+func (fake *fakeAPI) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(api.API_KEY_HEADER) != fakeAPIKey {
+		writeError(w, fakeError{http.StatusUnauthorized, "UNAUTHORIZED", "invalid API key", ""})
+		return
+	}
+	var body fakeEntity
+	if data, _ := ioutil.ReadAll(r.Body); len(data) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			writeError(w, fakeError{http.StatusBadRequest, "INVALID_JSON", err.Error(), ""})
+			return
+		}
+	}
+	if body == nil {
+		body = fakeEntity{}
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var data interface{}
+	var err error
+	switch {
+	case len(parts) == 2 && parts[0] == "tasks" && r.Method == http.MethodGet:
+		data, err = fake.pollTask(parts[1])
+	case parts[0] == "environments":
+		data, err = fake.handleEnvironments(r.Method, parts[1:], body)
+	case parts[0] == "organizations" && len(parts) == 1:
+		data = fake.organizations
+	case parts[0] == "users" && len(parts) == 1:
+		data = fake.listUsers(r.URL.Query().Get("organizationId"))
+	case len(parts) == 2 && parts[0] == "services" && parts[1] == "connections":
+		data = fake.serviceConnections
+	case (len(parts) == 4 || len(parts) == 5) && parts[0] == "services":
+		id := ""
+		if len(parts) == 5 {
+			id = parts[4]
+		}
+		fake.handleEntities(w, r, parts[1]+"/"+parts[2], parts[3], id, body)
+		return
+	default:
+		err = fakeError{http.StatusNotFound, "NOT_FOUND", "no endpoint " + r.URL.Path, ""}
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (fake *fakeAPI) handleEntities(w http.ResponseWriter, r *http.Request, environment, entityType, id string, body fakeEntity) {
+	if _, ok := fake.entities[environment]; !ok {
+		writeError(w, notFound("environment", environment))
+		return
+	}
+	query := r.URL.Query()
+	operation := query.Get("operation")
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": fake.list(environment, entityType, query)})
+	case r.Method == http.MethodGet:
+		entity := fake.find(environment, entityType, id)
+		if entity == nil {
+			writeError(w, notFound(entityType, id))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": entity})
+	case r.Method == http.MethodPost && operation != "":
+		fake.operations = append(fake.operations, entityType+"/"+operation)
+		fake.startTask(w, entityType, operation, func() (interface{}, error) {
+			return fake.execute(environment, entityType, id, operation, body)
+		})
+	case r.Method == http.MethodPost:
+		fake.startTask(w, entityType, "create", func() (interface{}, error) {
+			return fake.create(environment, entityType, body)
+		})
+	case r.Method == http.MethodPut && id != "":
+		fake.startTask(w, entityType, "update", func() (interface{}, error) {
+			entity := fake.find(environment, entityType, id)
+			if entity == nil {
+				return nil, notFound(entityType, id)
+			}
+			for key, value := range body {
+				if key != "id" {
+					entity[key] = value
+				}
+			}
+			return entity, nil
+		})
+	case r.Method == http.MethodDelete && id != "":
+		fake.startTask(w, entityType, "delete", func() (interface{}, error) {
+			return fake.delete(environment, entityType, id, body)
+		})
+	default:
+		writeError(w, fakeError{http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", r.Method + " " + r.URL.Path, ""})
+	}
+}
+
+// Applies a change and answers with a pending task. Errors of the request itself, such as a missing
+// entity, are returned right away. Failures set with fail make the task fail without applying the change.
+func (fake *fakeAPI) startTask(w http.ResponseWriter, entityType, operation string, apply func() (interface{}, error)) {
+	task := &fakeTask{id: fake.newID(), status: "SUCCESS"}
+	if errorCode, ok := fake.failures[entityType+"/"+operation]; ok {
+		task.status = "FAILED"
+		task.result = map[string]interface{}{
+			"errors": []map[string]interface{}{{
+				"errorCode": errorCode,
+				"message":   fmt.Sprintf("%s of %s failed", operation, entityType),
+			}},
+		}
+	} else {
+		result, err := apply()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		task.result = result
+	}
+	fake.tasks[task.id] = task
+	writeJSON(w, http.StatusOK, map[string]interface{}{"taskId": task.id, "taskStatus": "PENDING"})
+}
+
+func (fake *fakeAPI) pollTask(id string) (interface{}, error) {
+	task, ok := fake.tasks[id]
+	if !ok {
+		return nil, notFound("task", id)
+	}
+	task.polls++
+	if task.polls <= fake.pendingPolls {
+		return map[string]interface{}{
+			"id":       task.id,
+			"status":   "PENDING",
+			"progress": 100 * (task.polls - 1) / fake.pendingPolls,
+		}, nil
+	}
+	return map[string]interface{}{
+		"id":       task.id,
+		"status":   task.status,
+		"progress": 100,
+		"result":   task.result,
+	}, nil
+}
+
+func (fake *fakeAPI) collection(environment, entityType string) map[string]fakeEntity {
+	collections := fake.entities[environment]
+	if collections[entityType] == nil {
+		collections[entityType] = map[string]fakeEntity{}
+	}
+	return collections[entityType]
+}
+
+func (fake *fakeAPI) find(environment, entityType, id string) fakeEntity {
+	if id == "" {
+		return nil
+	}
+	return fake.entities[environment][entityType][strings.ToLower(id)]
+}
+
+// Lists the entities matching the options. Options such as vpc_id match the field vpcId.
+func (fake *fakeAPI) list(environment, entityType string, options map[string][]string) []fakeEntity {
+	entities := []fakeEntity{}
+	for _, entity := range fake.collection(environment, entityType) {
+		matches := true
+		for option, values := range options {
+			value, ok := entity[toCamelCase(option)]
+			if !ok || !strings.EqualFold(fmt.Sprint(value), values[0]) {
+				matches = false
+			}
+		}
+		if matches {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+func (fake *fakeAPI) create(environment, entityType string, entity fakeEntity) (interface{}, error) {
+	entity["id"] = fake.newID()
+	switch entityType {
+	case "sshkeys":
+		// SSH keys are identified by their name
+		if str(entity, "name") == "" {
+			return nil, badRequest("name", "name is required")
+		}
+		entity["id"] = strings.ToLower(str(entity, "name"))
+		entity["fingerprint"] = "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff"
+	case "affinitygroups":
+		entity["instanceIds"] = []string{}
+	case "instances":
+		if err := fake.createInstance(environment, entity); err != nil {
+			return nil, err
+		}
+	case "volumes":
+		if err := fake.createVolume(environment, entity); err != nil {
+			return nil, err
+		}
+	case "vpcs":
+		if err := fake.createVpc(environment, entity); err != nil {
+			return nil, err
+		}
+	case "networks":
+		vpc := fake.find(environment, "vpcs", str(entity, "vpcId"))
+		if vpc == nil {
+			return nil, badRequest("vpcId", "VPC not found")
+		}
+		if fake.find(environment, "networkofferings", str(entity, "networkOfferingId")) == nil {
+			return nil, badRequest("networkOfferingId", "network offering not found")
+		}
+		acl := fake.find(environment, "networkacls", str(entity, "networkAclId"))
+		if acl == nil {
+			return nil, badRequest("networkAclId", "network ACL not found")
+		}
+		fake.lastIP++
+		entity["networkAclName"] = acl["name"]
+		entity["cidr"] = fmt.Sprintf("10.%d.%d.0/24", fake.lastIP/250, fake.lastIP%250)
+		entity["state"] = "Implemented"
+		entity["zoneid"] = vpc["zoneId"]
+		entity["zonename"] = vpc["zoneName"]
+	case "networkacls":
+		if fake.find(environment, "vpcs", str(entity, "vpcId")) == nil {
+			return nil, badRequest("vpcId", "VPC not found")
+		}
+	case "networkaclrules":
+		if fake.find(environment, "networkacls", str(entity, "networkAclId")) == nil {
+			return nil, badRequest("networkAclId", "network ACL not found")
+		}
+		entity["state"] = "Active"
+	case "publicipaddresses":
+		vpc := fake.find(environment, "vpcs", str(entity, "vpcId"))
+		if vpc == nil {
+			return nil, badRequest("vpcId", "VPC not found")
+		}
+		entity["ipaddress"] = fake.newIP("172.31")
+		entity["state"] = "Allocated"
+		entity["zoneId"] = vpc["zoneId"]
+		entity["zoneName"] = vpc["zoneName"]
+	case "portforwardingrules":
+		if err := fake.createPortForwardingRule(environment, entity); err != nil {
+			return nil, err
+		}
+	case "loadbalancerrules":
+		publicIP := fake.find(environment, "publicipaddresses", str(entity, "publicIpId"))
+		if publicIP == nil {
+			return nil, badRequest("publicIpId", "public IP not found")
+		}
+		if fake.find(environment, "networks", str(entity, "networkId")) == nil {
+			return nil, badRequest("networkId", "network not found")
+		}
+		entity["publicIp"] = publicIP["ipaddress"]
+	case "vpnusers":
+		if str(entity, "username") == "" || str(entity, "password") == "" {
+			return nil, badRequest("username", "username and password are required")
+		}
+		delete(entity, "password")
+	default:
+		return nil, fakeError{http.StatusBadRequest, "NOT_SUPPORTED", "cannot create " + entityType, ""}
+	}
+	fake.collection(environment, entityType)[str(entity, "id")] = entity
+	return entity, nil
+}
+
+func (fake *fakeAPI) createInstance(environment string, instance fakeEntity) error {
+	template := fake.find(environment, "templates", str(instance, "templateId"))
+	if template == nil {
+		return badRequest("templateId", "template not found")
+	}
+	offering := fake.find(environment, "computeofferings", str(instance, "computeOfferingId"))
+	if offering == nil {
+		return badRequest("computeOfferingId", "compute offering not found")
+	}
+	if err := fake.setComputeOffering(instance, offering); err != nil {
+		return err
+	}
+	if err := fake.setNetwork(environment, instance, str(instance, "networkId")); err != nil {
+		return err
+	}
+	zoneID := str(instance, "zoneId")
+	if zoneID == "" {
+		zoneID = template["availableInZones"].([]interface{})[0].(string)
+	}
+	zone := fake.find(environment, "zones", zoneID)
+	if zone == nil {
+		return badRequest("zoneId", "zone not found")
+	}
+	if dedicatedGroupID := str(instance, "dedicatedGroupId"); dedicatedGroupID != "" {
+		instance["affinityGroupIds"] = append(strs(instance, "affinityGroupIds"), dedicatedGroupID)
+	}
+	instance["state"] = "Running"
+	instance["templateName"] = template["name"]
+	instance["zoneId"] = zone["id"]
+	instance["zoneName"] = zone["name"]
+	instance["username"] = "root"
+	return nil
+}
+
+func (fake *fakeAPI) setComputeOffering(instance fakeEntity, offering fakeEntity) error {
+	if offering["custom"] == true {
+		if instance["cpuCount"] == nil || instance["memoryInMB"] == nil {
+			return badRequest("cpuCount", "cpuCount and memoryInMB are required by a custom compute offering")
+		}
+	} else {
+		instance["cpuCount"] = offering["cpuCount"]
+		instance["memoryInMB"] = offering["memoryInMB"]
+	}
+	instance["computeOfferingId"] = offering["id"]
+	instance["computeOfferingName"] = offering["name"]
+	return nil
+}
+
+// Moves the instance to the network, with a new private IP
+func (fake *fakeAPI) setNetwork(environment string, instance fakeEntity, networkID string) error {
+	network := fake.find(environment, "networks", networkID)
+	if network == nil {
+		return badRequest("networkId", "network not found")
+	}
+	instance["networkId"] = network["id"]
+	instance["networkName"] = network["name"]
+	instance["vpcId"] = network["vpcId"]
+	instance["ipAddress"] = fake.newIP("10.0")
+	instance["ipAddressId"] = fake.newID()
+	return nil
+}
+
+func (fake *fakeAPI) createVolume(environment string, volume fakeEntity) error {
+	offering := fake.find(environment, "diskofferings", str(volume, "diskOfferingId"))
+	if offering == nil {
+		return badRequest("diskOfferingId", "disk offering not found")
+	}
+	if volume["sizeInGb"] == nil {
+		volume["sizeInGb"] = offering["gbSize"]
+	} else if offering["customSize"] != true {
+		return badRequest("sizeInGb", "the disk offering doesn't allow a custom size")
+	}
+	if volume["iops"] == nil {
+		volume["iops"] = offering["minIops"]
+	}
+	zoneID := str(volume, "zoneId")
+	if instanceID := str(volume, "instanceId"); instanceID != "" {
+		instance := fake.find(environment, "instances", instanceID)
+		if instance == nil {
+			return badRequest("instanceId", "instance not found")
+		}
+		volume["instanceName"] = instance["name"]
+		if zoneID == "" {
+			zoneID = str(instance, "zoneId")
+		}
+	}
+	zone := fake.find(environment, "zones", zoneID)
+	if zone == nil {
+		zone = fake.find(environment, "zones", fake.firstID(environment, "zones"))
+	}
+	volume["type"] = "DATA"
+	volume["state"] = "Ready"
+	volume["diskOfferingName"] = offering["name"]
+	volume["zoneId"] = zone["id"]
+	volume["zoneName"] = zone["name"]
+	return nil
+}
+
+// Creates a VPC with its default network ACLs, its source NAT IP and its remote access VPN
+func (fake *fakeAPI) createVpc(environment string, vpc fakeEntity) error {
+	if fake.find(environment, "vpcofferings", str(vpc, "vpcOfferingId")) == nil {
+		return badRequest("vpcOfferingId", "VPC offering not found")
+	}
+	zone := fake.find(environment, "zones", str(vpc, "zoneId"))
+	if zone == nil {
+		if str(vpc, "zoneId") != "" {
+			return badRequest("zoneId", "zone not found")
+		}
+		zone = fake.find(environment, "zones", fake.firstID(environment, "zones"))
+	}
+	if str(vpc, "networkDomain") == "" {
+		vpc["networkDomain"] = "cs1cloud.internal"
+	}
+	vpc["state"] = "Enabled"
+	vpc["cidr"] = "10.0.0.0/16"
+	vpc["zoneId"] = zone["id"]
+	vpc["zoneName"] = zone["name"]
+
+	for _, name := range []string{"default_allow", "default_deny"} {
+		acl := fakeEntity{"id": fake.newID(), "name": name, "description": name, "vpcId": vpc["id"]}
+		fake.collection(environment, "networkacls")[str(acl, "id")] = acl
+	}
+	sourceNAT := fakeEntity{
+		"id":        fake.newID(),
+		"ipaddress": fake.newIP("172.31"),
+		"state":     "Allocated",
+		"vpcId":     vpc["id"],
+		"zoneId":    zone["id"],
+		"zoneName":  zone["name"],
+		"purposes":  []string{"SOURCE_NAT"},
+	}
+	fake.collection(environment, "publicipaddresses")[str(sourceNAT, "id")] = sourceNAT
+	fake.collection(environment, "remoteaccessvpns")[str(sourceNAT, "id")] = fakeEntity{
+		"id":                sourceNAT["id"],
+		"publicIpAddress":   sourceNAT["ipaddress"],
+		"publicIpAddressId": sourceNAT["id"],
+		"state":             "Disabled",
+		"type":              "L2TP",
+		"vpcId":             vpc["id"],
+	}
+	return nil
+}
+
+func (fake *fakeAPI) createPortForwardingRule(environment string, rule fakeEntity) error {
+	publicIP := fake.find(environment, "publicipaddresses", str(rule, "ipAddressId"))
+	if publicIP == nil {
+		return badRequest("ipAddressId", "public IP not found")
+	}
+	instance := fake.instanceWithPrivateIP(environment, str(rule, "privateIpId"))
+	if instance == nil {
+		return badRequest("privateIpId", "private IP not found")
+	}
+	if str(rule, "publicPortEnd") == "" {
+		rule["publicPortEnd"] = rule["publicPortStart"]
+	}
+	if str(rule, "privatePortEnd") == "" {
+		rule["privatePortEnd"] = rule["privatePortStart"]
+	}
+	rule["ipAddress"] = publicIP["ipaddress"]
+	rule["privateIp"] = instance["ipAddress"]
+	rule["instanceId"] = instance["id"]
+	rule["instanceName"] = instance["name"]
+	rule["networkId"] = instance["networkId"]
+	rule["vpcId"] = publicIP["vpcId"]
+	rule["state"] = "Active"
+	return nil
+}
+
+func (fake *fakeAPI) instanceWithPrivateIP(environment, privateIPID string) fakeEntity {
+	for _, instance := range fake.collection(environment, "instances") {
+		if strings.EqualFold(str(instance, "ipAddressId"), privateIPID) {
+			return instance
+		}
+	}
+	return nil
+}
+
+func (fake *fakeAPI) delete(environment, entityType, id string, options fakeEntity) (interface{}, error) {
+	entity := fake.find(environment, entityType, id)
+	if entity == nil {
+		return nil, notFound(entityType, id)
+	}
+	switch entityType {
+	case "instances":
+		for _, volumeID := range strs(options, "volumeIdsToDelete") {
+			delete(fake.collection(environment, "volumes"), volumeID)
+		}
+		for _, publicIPID := range strs(options, "publicIpIdsToRelease") {
+			delete(fake.collection(environment, "publicipaddresses"), publicIPID)
+		}
+		if options["purgeImmediately"] != true {
+			entity["state"] = "Destroyed"
+			return map[string]interface{}{}, nil
+		}
+	case "vpcs":
+		for _, related := range []string{"networkacls", "publicipaddresses", "remoteaccessvpns", "networks"} {
+			for relatedID, relatedEntity := range fake.collection(environment, related) {
+				if strings.EqualFold(str(relatedEntity, "vpcId"), id) {
+					delete(fake.collection(environment, related), relatedID)
+				}
+			}
+		}
+	case "networkacls":
+		for _, rule := range fake.collection(environment, "networkaclrules") {
+			if strings.EqualFold(str(rule, "networkAclId"), id) {
+				delete(fake.collection(environment, "networkaclrules"), str(rule, "id"))
+			}
+		}
+	}
+	delete(fake.collection(environment, entityType), str(entity, "id"))
+	return map[string]interface{}{}, nil
+}
+
+func (fake *fakeAPI) execute(environment, entityType, id, operation string, body fakeEntity) (interface{}, error) {
+	entity := fake.find(environment, entityType, id)
+	if entity == nil {
+		return nil, notFound(entityType, id)
+	}
+	switch entityType + "/" + operation {
+	case "instances/start", "instances/reboot":
+		entity["state"] = "Running"
+	case "instances/stop":
+		entity["state"] = "Stopped"
+	case "instances/recover":
+		if str(entity, "state") != "Destroyed" {
+			return nil, fakeError{http.StatusBadRequest, "INVALID_STATE", "the instance isn't destroyed", ""}
+		}
+		entity["state"] = "Stopped"
+	case "instances/purge":
+		delete(fake.collection(environment, entityType), str(entity, "id"))
+	case "instances/changeComputeOffering":
+		offering := fake.find(environment, "computeofferings", str(body, "computeOfferingId"))
+		if offering == nil {
+			return nil, badRequest("computeOfferingId", "compute offering not found")
+		}
+		entity["cpuCount"] = body["cpuCount"]
+		entity["memoryInMB"] = body["memoryInMB"]
+		if err := fake.setComputeOffering(entity, offering); err != nil {
+			return nil, err
+		}
+	case "instances/associateSSHKey":
+		entity["sshKeyName"] = body["sshKeyName"]
+	case "instances/changeAffinityGroups":
+		entity["affinityGroupIds"] = strs(body, "affinityGroupIds")
+	case "instances/changeNetwork":
+		if err := fake.setNetwork(environment, entity, str(body, "networkId")); err != nil {
+			return nil, err
+		}
+	case "instances/resetPassword":
+		entity["password"] = "new-password"
+	case "instances/createRecoveryPoint", "vpcs/restart":
+	case "volumes/resize":
+		if body["sizeInGb"] != nil {
+			entity["sizeInGb"] = body["sizeInGb"]
+		}
+		if body["iops"] != nil {
+			entity["iops"] = body["iops"]
+		}
+	case "volumes/attachToInstance":
+		instance := fake.find(environment, "instances", str(body, "instanceId"))
+		if instance == nil {
+			return nil, badRequest("instanceId", "instance not found")
+		}
+		entity["instanceId"] = instance["id"]
+		entity["instanceName"] = instance["name"]
+	case "volumes/detachFromInstance":
+		delete(entity, "instanceId")
+		delete(entity, "instanceName")
+	case "networks/replace":
+		acl := fake.find(environment, "networkacls", str(body, "networkAclId"))
+		if acl == nil {
+			return nil, badRequest("networkAclId", "network ACL not found")
+		}
+		entity["networkAclId"] = acl["id"]
+		entity["networkAclName"] = acl["name"]
+	case "publicipaddresses/enableStaticNat":
+		instance := fake.instanceWithPrivateIP(environment, str(body, "privateIpId"))
+		if instance == nil {
+			return nil, badRequest("privateIpId", "private IP not found")
+		}
+		entity["privateIpId"] = body["privateIpId"]
+		entity["instanceId"] = instance["id"]
+		entity["purposes"] = []string{"STATIC_NAT"}
+	case "publicipaddresses/disableStaticNat":
+		delete(entity, "privateIpId")
+		delete(entity, "instanceId")
+		entity["purposes"] = []string{}
+	case "loadbalancerrules/updateInstances":
+		entity["instanceIds"] = strs(body, "instanceIds")
+	case "loadbalancerrules/updateStickiness":
+		if str(body, "stickinessMethod") == "none" {
+			delete(entity, "stickinessMethod")
+			delete(entity, "stickinessPolicyParameters")
+		} else {
+			entity["stickinessMethod"] = body["stickinessMethod"]
+			entity["stickinessPolicyParameters"] = body["stickinessPolicyParameters"]
+		}
+	case "remoteaccessvpns/enable":
+		entity["state"] = "Running"
+		entity["presharedKey"] = "preshared-key"
+	case "remoteaccessvpns/disable":
+		entity["state"] = "Disabled"
+		delete(entity, "presharedKey")
+	default:
+		return nil, fakeError{http.StatusBadRequest, "INVALID_OPERATION", fmt.Sprintf("no operation %s on %s", operation, entityType), ""}
+	}
+	return entity, nil
+}
+
+func (fake *fakeAPI) handleEnvironments(method string, parts []string, body fakeEntity) (interface{}, error) {
+	switch {
+	case method == http.MethodGet && len(parts) == 0:
+		environments := []fakeEntity{}
+		for _, environment := range fake.environments {
+			environments = append(environments, environment)
+		}
+		return environments, nil
+	case method == http.MethodPost && len(parts) == 0:
+		return fake.createEnvironment(body)
+	case len(parts) != 1:
+		return nil, fakeError{http.StatusNotFound, "NOT_FOUND", "no such endpoint", ""}
+	}
+	environment, ok := fake.environments[parts[0]]
+	if !ok {
+		return nil, notFound("environment", parts[0])
+	}
+	switch method {
+	case http.MethodGet:
+		return environment, nil
+	case http.MethodPut:
+		key := fake.environmentKey(environment)
+		if err := fake.setEnvironment(environment, body); err != nil {
+			return nil, err
+		}
+		// the name of the environment is part of the URL of its entities
+		entities := fake.entities[key]
+		delete(fake.entities, key)
+		fake.entities[fake.environmentKey(environment)] = entities
+		return environment, nil
+	case http.MethodDelete:
+		delete(fake.entities, fake.environmentKey(environment))
+		delete(fake.environments, parts[0])
+		return map[string]interface{}{}, nil
+	}
+	return nil, fakeError{http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", method + " /environments", ""}
+}
+
+func (fake *fakeAPI) createEnvironment(body fakeEntity) (fakeEntity, error) {
+	environment := fakeEntity{"id": fake.newID()}
+	if err := fake.setEnvironment(environment, body); err != nil {
+		return nil, err
+	}
+	key := fake.environmentKey(environment)
+	if _, ok := fake.entities[key]; ok {
+		return nil, badRequest("name", "an environment with this name already exists")
+	}
+	fake.environments[str(environment, "id")] = environment
+	fake.entities[key] = map[string]map[string]fakeEntity{}
+	fake.seedCatalogues(key)
+	return environment, nil
+}
+
+// Sets the name, description, organization, service connection and roles of the environment from the body
+func (fake *fakeAPI) setEnvironment(environment fakeEntity, body fakeEntity) error {
+	organizationID := str(entityOf(body, "organization"), "id")
+	if organizationID == "" {
+		organizationID = str(entityOf(environment, "organization"), "id")
+	}
+	organization := findByID(fake.organizations, organizationID)
+	if organization == nil {
+		return badRequest("organization", "organization not found")
+	}
+	connectionID := str(entityOf(body, "serviceConnection"), "id")
+	if connectionID == "" {
+		connectionID = str(entityOf(environment, "serviceConnection"), "id")
+	}
+	connection := findByID(fake.serviceConnections, connectionID)
+	if connection == nil {
+		return badRequest("serviceConnection", "service connection not found")
+	}
+	roles := []fakeEntity{}
+	if bodyRoles, ok := body["roles"].([]interface{}); ok {
+		for _, bodyRole := range bodyRoles {
+			role := fakeEntity(bodyRole.(map[string]interface{}))
+			users := []fakeEntity{}
+			if roleUsers, ok := role["users"].([]interface{}); ok {
+				for _, roleUser := range roleUsers {
+					userID := str(fakeEntity(roleUser.(map[string]interface{})), "id")
+					user := findByID(fake.users, userID)
+					if user == nil {
+						return badRequest("users", "user "+userID+" not found")
+					}
+					users = append(users, fakeEntity{"id": user["id"], "username": user["username"]})
+				}
+			}
+			roles = append(roles, fakeEntity{"name": role["name"], "users": users})
+		}
+	}
+	environment["name"] = body["name"]
+	environment["description"] = body["description"]
+	environment["organization"] = organization
+	environment["serviceConnection"] = connection
+	environment["roles"] = roles
+	environment["users"] = []fakeEntity{}
+	return nil
+}
+
+func (fake *fakeAPI) environmentKey(environment fakeEntity) string {
+	return str(entityOf(environment, "serviceConnection"), "serviceCode") + "/" + str(environment, "name")
+}
+
+func (fake *fakeAPI) listUsers(organizationID string) []fakeEntity {
+	users := []fakeEntity{}
+	for _, user := range fake.users {
+		if organizationID == "" || strings.EqualFold(str(entityOf(user, "organization"), "id"), organizationID) {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// Adds the zones, templates and offerings of an environment
+func (fake *fakeAPI) seedCatalogues(environment string) {
+	add := func(entityType string, entity fakeEntity) string {
+		entity["id"] = fake.newID()
+		fake.collection(environment, entityType)[str(entity, "id")] = entity
+		return str(entity, "id")
+	}
+	zoneID := add("zones", fakeEntity{"name": fakeZone})
+	otherZoneID := add("zones", fakeEntity{"name": fakeOtherZone})
+	add("templates", fakeEntity{
+		"name":             fakeTemplate,
+		"size":             10,
+		"ready":            true,
+		"resizable":        true,
+		"sshKeyEnabled":    true,
+		"zoneId":           zoneID,
+		"availableInZones": []interface{}{zoneID, otherZoneID},
+	})
+	add("computeofferings", fakeEntity{"name": fakeComputeOffering, "cpuCount": 1, "memoryInMB": 1024})
+	add("computeofferings", fakeEntity{"name": fakeOtherComputeOffering, "cpuCount": 2, "memoryInMB": 4096})
+	add("computeofferings", fakeEntity{"name": fakeCustomComputeOffering, "custom": true})
+	add("diskofferings", fakeEntity{"name": fakeDiskOffering, "gbSize": 20, "minIops": 20, "maxIops": 20})
+	add("diskofferings", fakeEntity{"name": fakeCustomDiskOffering, "customSize": true, "customIops": true, "minIops": 100, "maxIops": 1000})
+	add("vpcofferings", fakeEntity{"name": fakeVpcOffering, "state": "Enabled"})
+	add("networkofferings", fakeEntity{"name": fakeNetworkOffering})
+	add("networkofferings", fakeEntity{"name": fakeLoadBalancedNetworkOffr})
+}
+
+func (fake *fakeAPI) firstID(environment, entityType string) string {
+	first := ""
+	for id := range fake.collection(environment, entityType) {
+		if first == "" || id < first {
+			first = id
+		}
+	}
+	return first
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	fakeErr, ok := err.(fakeError)
+	if !ok {
+		fakeErr = fakeError{http.StatusInternalServerError, "INTERNAL_ERROR", err.Error(), ""}
+	}
+	hciError := map[string]interface{}{"errorCode": fakeErr.code, "message": fakeErr.message}
+	if fakeErr.field != "" {
+		hciError["context"] = map[string]interface{}{"field": fakeErr.field}
+	}
+	writeJSON(w, fakeErr.status, map[string]interface{}{"errors": []interface{}{hciError}})
+}
+
+func str(entity map[string]interface{}, key string) string {
+	if value, ok := entity[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+func strs(entity map[string]interface{}, key string) []string {
+	values := []string{}
+	switch list := entity[key].(type) {
+	case []interface{}:
+		for _, value := range list {
+			values = append(values, fmt.Sprint(value))
+		}
+	case []string:
+		values = append(values, list...)
+	}
+	return values
+}
+
+func entityOf(entity map[string]interface{}, key string) fakeEntity {
+	switch value := entity[key].(type) {
+	case map[string]interface{}:
+		return value
+	case fakeEntity:
+		return value
+	}
+	return fakeEntity{}
+}
+
+func findByID(entities []fakeEntity, id string) fakeEntity {
+	for _, entity := range entities {
+		if id != "" && strings.EqualFold(str(entity, "id"), id) {
+			return entity
+		}
+	}
+	return nil
+}
+
+func copyEntity(entity fakeEntity) fakeEntity {
+	data, _ := json.Marshal(entity)
+	copied := fakeEntity{}
+	json.Unmarshal(data, &copied)
+	return copied
+}
+
+// Converts an option such as network_acl_id to the name of its field, networkAclId
+func toCamelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// Returns a provider meta whose client uses the fake API with the key
+func (fake *fakeAPI) meta(t *testing.T, apiKey string) *providerMeta {
+	config := Config{APIURL: fake.server.URL, APIKey: apiKey}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &providerMeta{client: client, cache: newResourceCache(), defaultEnvironmentID: fake.environmentID}
+}
+
+func TestFakeAPIRejectsInvalidAPIKey(t *testing.T) {
+	fake := newFakeAPI(t)
+	meta := fake.meta(t, "invalid")
+
+	_, err := meta.client.Environments.Get(fake.environmentID)
+	var errorResponse api.HciErrorResponse
+	if !errors.As(err, &errorResponse) || errorResponse.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+}
+
+func TestFakeAPIEntityLifecycle(t *testing.T) {
+	fake := newFakeAPI(t)
+	meta := fake.meta(t, fakeAPIKey)
+	resources, err := getResourcesForEnvironmentID(context.Background(), meta, fake.environmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vpc, err := resources.Vpcs.Create(hci.Vpc{Name: "vpc", VpcOfferingId: fake.idOf("vpcofferings", fakeVpcOffering)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vpc.Id == "" || vpc.ZoneName != fakeZone {
+		t.Errorf("unexpected VPC %+v", vpc)
+	}
+	acls, err := resources.NetworkAcls.ListByVpcId(vpc.Id)
+	if err != nil || len(acls) != 2 {
+		t.Fatalf("expected the default ACLs of the VPC, got %v, %v", acls, err)
+	}
+	network, err := resources.Networks.Create(hci.Network{
+		Name:              "network",
+		VpcId:             vpc.Id,
+		NetworkOfferingId: fake.idOf("networkofferings", fakeNetworkOffering),
+		NetworkAclId:      acls[0].Id,
+	}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := resources.Instances.Create(hci.Instance{
+		Name:              "instance",
+		TemplateId:        fake.idOf("templates", fakeTemplate),
+		ComputeOfferingId: fake.idOf("computeofferings", fakeComputeOffering),
+		NetworkId:         network.Id,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !instance.IsRunning() || instance.CpuCount != 1 || instance.IpAddress == "" || instance.VpcId != vpc.Id {
+		t.Errorf("unexpected instance %+v", instance)
+	}
+
+	if _, err := resources.Instances.Stop(instance.Id); err != nil {
+		t.Fatal(err)
+	}
+	if instance, err = resources.Instances.Get(instance.Id); err != nil || !instance.IsStopped() {
+		t.Errorf("expected a stopped instance, got %+v, %v", instance, err)
+	}
+	if !fake.executed("instances", "stop") {
+		t.Error("expected the stop operation to be executed")
+	}
+
+	if _, err := resources.Instances.DestroyWithOptions(instance.Id, hci.DestroyOptions{PurgeImmediately: true}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = resources.Instances.Get(instance.Id)
+	var errorResponse api.HciErrorResponse
+	if !errors.As(err, &errorResponse) || errorResponse.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the purged instance not to be found, got %v", err)
+	}
+}
+
+func TestFakeAPIPendingTask(t *testing.T) {
+	fake := newFakeAPI(t)
+	fake.pendingPolls = 2
+	meta := fake.meta(t, fakeAPIKey)
+
+	response, err := meta.client.GetApiClient().Do(api.HciRequest{
+		Method:   api.POST,
+		Endpoint: "services/" + fakeServiceCode + "/" + fakeEnvironmentName + "/sshkeys",
+		Body:     []byte(`{"name":"key","publicKey":"ssh-rsa AAAA"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.TaskId == "" || response.TaskStatus != "PENDING" {
+		t.Fatalf("expected a pending task, got %+v", response)
+	}
+	var progress []int
+	if _, err := meta.client.Tasks.PollWithProgress(context.Background(), response.TaskId, 1, func(task services.Task) {
+		progress = append(progress, task.Progress)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 3 || progress[0] != 0 || progress[1] != 50 || progress[2] != 100 {
+		t.Errorf("unexpected progress %v", progress)
+	}
+	if fake.get("sshkeys", "key") == nil {
+		t.Error("expected the SSH key to be created")
+	}
+}
+
+func TestFakeAPIFailedTask(t *testing.T) {
+	fake := newFakeAPI(t)
+	fake.fail("vpcs", "create", "QUOTA_EXCEEDED")
+	meta := fake.meta(t, fakeAPIKey)
+	resources, err := getResourcesForEnvironmentID(context.Background(), meta, fake.environmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = resources.Vpcs.Create(hci.Vpc{Name: "vpc", VpcOfferingId: fake.idOf("vpcofferings", fakeVpcOffering)})
+	var failedTask services.FailedTask
+	if !errors.As(err, &failedTask) || len(failedTask.Errors) != 1 || failedTask.Errors[0].ErrorCode != "QUOTA_EXCEEDED" {
+		t.Fatalf("expected a failed task, got %v", err)
+	}
+	if vpcs, _ := resources.Vpcs.List(); len(vpcs) != 0 {
+		t.Errorf("expected no VPC to be created, got %+v", vpcs)
+	}
+}
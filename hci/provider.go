@@ -1,9 +1,15 @@
 package hci
 
 import (
+	"context"
+	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -14,30 +20,249 @@ func Provider() *schema.Provider {
 			"api_url": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				DefaultFunc: schema.EnvDefaultFunc("HCI_API_URL", "https://hypertec.cloud/api/v1"),
+				DefaultFunc: schema.EnvDefaultFunc("HCI_API_URL", ""),
 			},
 			"api_key": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("HCI_API_KEY", nil),
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_API_KEY", ""),
+			},
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_PROFILE", ""),
+				Description: "Profile of the credentials file holding api_url, api_key and insecure",
+			},
+			"shared_credentials_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_SHARED_CREDENTIALS_FILE", ""),
+				Description: "Path of the credentials file, ~/.hci/credentials by default",
+			},
+			"insecure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Whether to skip the verification of the API server certificate",
+			},
+			"ca_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_CA_CERT_FILE", ""),
+				Description: "Path of a PEM file of certificate authorities trusted to verify the API server certificate",
+			},
+			"ca_cert_pem": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "PEM certificates of certificate authorities trusted to verify the API server certificate",
+			},
+			"client_cert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_CLIENT_CERT", ""),
+				Description: "PEM certificate presented to the API server for mutual TLS, or path of a PEM file",
+			},
+			"client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_CLIENT_KEY", ""),
+				Description: "PEM private key of client_cert, or path of a PEM file",
+			},
+			"proxy_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HCI_PROXY_URL", ""),
+				ValidateFunc: validateProxyURL,
+				Description:  "URL of the HTTP proxy of the API requests, read from HTTP_PROXY, HTTPS_PROXY and NO_PROXY by default",
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validateNonNegativeInt,
+				Description:  "Timeout in seconds of an attempt of an API request, 0 for no timeout",
+			},
+			"idle_conn_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      90,
+				ValidateFunc: validateNonNegativeInt,
+				Description:  "Number of seconds idle connections to the API are kept open, 0 for no limit",
+			},
+			"default_environment_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_DEFAULT_ENVIRONMENT_ID", ""),
+				Description: "ID of the environment of the resources and data sources that don't set environment_id",
+			},
+			"default_service_code": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_DEFAULT_SERVICE_CODE", ""),
+				Description: "Service code of the environments that don't set service_code",
+			},
+			"default_organization_code": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HCI_DEFAULT_ORGANIZATION_CODE", ""),
+				Description: "Organization's entry point of the environments that don't set organization_code",
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      4,
+				Description:  "Maximum number of times a request is retried after a transient failure",
+				ValidateFunc: validateNonNegativeInt,
+			},
+			"retry_max_wait": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				Description:  "Maximum number of seconds to wait between two attempts of a request",
+				ValidateFunc: validateNonNegativeInt,
+			},
+			"requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      0,
+				Description:  "Average number of API requests sent per second. Defaults to 0, no limit",
+				ValidateFunc: validateNonNegativeFloat,
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				Description:  "Maximum number of API requests in flight. Defaults to 0, no limit",
+				ValidateFunc: validateNonNegativeInt,
 			},
 		},
 		ResourcesMap: mergeResourceMaps(
 			GetHciResourceMap(),
 		),
-		ConfigureFunc: providerConfigure,
+		DataSourcesMap: mergeResourceMaps(
+			GetHciDataSourceMap(),
+		),
+		ConfigureContextFunc: providerConfigure,
 	}
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
-	insecure, _ := strconv.ParseBool(os.Getenv("HCI_INSECURE_CONNECTION"))
-	config := Config{
-		APIURL:   d.Get("api_url").(string),
-		APIKey:   d.Get("api_key").(string),
-		Insecure: insecure,
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	config, diags := providerClientConfig(ctx, d)
+	if diags.HasError() {
+		return nil, diags
+	}
+	return newProviderMeta(ctx, d, config)
+}
+
+// Returns the configuration of the client of the provider, from the provider configuration, the environment
+// variables and the credentials file
+func providerClientConfig(ctx context.Context, d *schema.ResourceData) (*Config, diag.Diagnostics) {
+	profile, err := getCredentialsProfile(ctx, d)
+	if err != nil {
+		return nil, diag.FromErr(err)
 	}
 
-	return config.NewClient()
+	// the provider configuration and environment variables take precedence over the profile
+	apiURL := d.Get("api_url").(string)
+	if apiURL == "" {
+		apiURL = profile.APIURL
+	}
+	if apiURL == "" {
+		apiURL = defaultAPIURL
+	}
+	apiKey := d.Get("api_key").(string)
+	if apiKey == "" {
+		apiKey = profile.APIKey
+	}
+	if apiKey == "" {
+		return nil, diag.Errorf("api_key must be set in the provider configuration, with HCI_API_KEY or in a profile of the credentials file")
+	}
+	var insecure bool
+	if val, ok := d.GetOkExists("insecure"); ok {
+		insecure = val.(bool)
+	} else if val, ok := os.LookupEnv("HCI_INSECURE_CONNECTION"); ok {
+		insecure, _ = strconv.ParseBool(val)
+	} else if profile.Insecure != nil {
+		insecure = *profile.Insecure
+	}
+
+	return &Config{
+		APIURL:                apiURL,
+		APIKey:                apiKey,
+		Insecure:              insecure,
+		CACertFile:            d.Get("ca_cert_file").(string),
+		CACertPEM:             d.Get("ca_cert_pem").(string),
+		ClientCert:            d.Get("client_cert").(string),
+		ClientKey:             d.Get("client_key").(string),
+		ProxyURL:              d.Get("proxy_url").(string),
+		RequestTimeout:        time.Duration(d.Get("request_timeout").(int)) * time.Second,
+		IdleConnTimeout:       time.Duration(d.Get("idle_conn_timeout").(int)) * time.Second,
+		MaxRetries:            d.Get("max_retries").(int),
+		RetryMaxWait:          time.Duration(d.Get("retry_max_wait").(int)) * time.Second,
+		RequestsPerSecond:     d.Get("requests_per_second").(float64),
+		MaxConcurrentRequests: d.Get("max_concurrent_requests").(int),
+	}, nil
+}
+
+// Returns the meta of the provider, with a client of the configuration
+func newProviderMeta(ctx context.Context, d *schema.ResourceData, config *Config) (*providerMeta, diag.Diagnostics) {
+	if err := ctx.Err(); err != nil {
+		return nil, diag.FromErr(err)
+	}
+	client, err := config.NewClient()
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	return &providerMeta{
+		client:                  client,
+		cache:                   newResourceCache(),
+		defaultEnvironmentID:    d.Get("default_environment_id").(string),
+		defaultServiceCode:      d.Get("default_service_code").(string),
+		defaultOrganizationCode: strings.ToLower(d.Get("default_organization_code").(string)),
+	}, nil
+}
+
+// Reads the profile chosen with profile or HCI_PROFILE, or the default profile, unless the context is done
+func getCredentialsProfile(ctx context.Context, d *schema.ResourceData) (*credentialsProfile, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	profile := d.Get("profile").(string)
+	path := d.Get("shared_credentials_file").(string)
+	explicit := profile != "" || path != ""
+	if profile == "" {
+		profile = defaultProfile
+	}
+	if path == "" {
+		path = defaultCredentialsFile
+	}
+	return readCredentialsProfile(path, profile, explicit)
+}
+
+func validateProxyURL(val interface{}, key string) (warns []string, errs []error) {
+	if val.(string) == "" {
+		return
+	}
+	proxyURL, err := url.Parse(val.(string))
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		errs = append(errs, fmt.Errorf("%q must be a URL such as http://proxy.example.com:3128, got: %s", key, val.(string)))
+	}
+	return
+}
+
+func validateNonNegativeInt(val interface{}, key string) (warns []string, errs []error) {
+	if val.(int) < 0 {
+		errs = append(errs, fmt.Errorf("%q must be a non-negative number, got: %d", key, val.(int)))
+	}
+	return
+}
+
+func validateNonNegativeFloat(val interface{}, key string) (warns []string, errs []error) {
+	if val.(float64) < 0 {
+		errs = append(errs, fmt.Errorf("%q must be a non-negative number, got: %v", key, val.(float64)))
+	}
+	return
 }
 
 func mergeResourceMaps(resourceMaps ...map[string]*schema.Resource) map[string]*schema.Resource {
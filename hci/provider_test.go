@@ -3,6 +3,7 @@ package hci
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -21,6 +22,7 @@ var testAccProvider *schema.Provider
 
 func init() {
 	testAccProvider = Provider()
+	testAccProvider.ConfigureContextFunc = configureWithCassettes
 	testAccProviders = map[string]*schema.Provider{
 		"hci": testAccProvider,
 	}
@@ -37,6 +39,29 @@ func TestProvider_impl(t *testing.T) {
 	var _ *schema.Provider = Provider()
 }
 
+func TestGetEnvironmentIDFallsBackToProviderDefault(t *testing.T) {
+	meta := &providerMeta{defaultEnvironmentID: environmentID}
+
+	d := schema.TestResourceDataRaw(t, dataSourceHciZones().Schema, map[string]interface{}{})
+	id, err := getEnvironmentID(d, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != environmentID || d.Get("environment_id").(string) != environmentID {
+		t.Errorf("expected the default environment %s, got %s", environmentID, id)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceHciZones().Schema, map[string]interface{}{"environment_id": vpcID})
+	if id, _ := getEnvironmentID(d, meta); id != vpcID {
+		t.Errorf("expected the environment %s of the data source, got %s", vpcID, id)
+	}
+
+	d = schema.TestResourceDataRaw(t, dataSourceHciZones().Schema, map[string]interface{}{})
+	if _, err := getEnvironmentID(d, &providerMeta{}); err == nil {
+		t.Error("expected an error without environment_id nor default_environment_id")
+	}
+}
+
 func hasEnvValue(envKey string) bool {
 	if v := os.Getenv(envKey); v == "" {
 		return false
@@ -54,5 +79,19 @@ func testAccPreCheckEnvs(t *testing.T, keys ...string) {
 }
 
 func testAccPreCheck(t *testing.T) {
-	testAccPreCheckEnvs(t, hciAPIKey)
+	if mode := accTestMode(t); mode == recordMode || mode == liveMode {
+		testAccPreCheckEnvs(t, hciAPIKey)
+	}
+	useCassette(t)
+}
+
+// Skips the unit tests running Terraform against the fake API when the Terraform CLI isn't available,
+// because the test framework would otherwise try to download it
+func testUnitPreCheck(t *testing.T) {
+	if os.Getenv("TF_ACC_TERRAFORM_PATH") != "" {
+		return
+	}
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("Terraform CLI not found, set TF_ACC_TERRAFORM_PATH to run this test")
+	}
 }
@@ -1,6 +1,7 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"regexp"
@@ -14,6 +15,7 @@ import (
 // GetHciResourceMap return the available Resource map
 func GetHciResourceMap() map[string]*schema.Resource {
 	return map[string]*schema.Resource{
+		"hci_affinity_group":       resourceHciAffinityGroup(),
 		"hci_environment":          resourceHciEnvironment(),
 		"hci_instance":             resourceHciInstance(),
 		"hci_load_balancer_rule":   resourceHciLoadBalancerRule(),
@@ -60,20 +62,63 @@ func handleNotFoundError(entity string, deleted bool, err error, d *schema.Resou
 
 // Deals with all of the casting done to get a hci.Resources.
 func getResources(d *schema.ResourceData, meta interface{}) hci.Resources {
-	client := meta.(*hc.HciClient)
+	client := meta.(*providerMeta).client
 	_resources, _ := client.GetResources(d.Get("service_code").(string), d.Get("environment_name").(string))
 	return _resources.(hci.Resources)
 }
 
-// Deals with all of the casting done to get a hci.Resources.
-func getResourcesForEnvironmentID(client *hc.HciClient, environmentID string) (hci.Resources, error) {
-	environment, err := client.Environments.Get(environmentID)
-	if err != nil {
-		return hci.Resources{}, err
+// Deals with all of the casting done to get a hci.Resources. The resources of every environment
+// are cached by the provider, so the environment is only looked up once.
+func getResourcesForEnvironmentID(ctx context.Context, meta interface{}, environmentID string) (hci.Resources, error) {
+	providerMeta := meta.(*providerMeta)
+	return providerMeta.cache.getResources(ctx, getClient(ctx, meta), environmentID)
+}
+
+// Returns the client of the provider, bound to the context
+func getClient(ctx context.Context, meta interface{}) *hc.HciClient {
+	return meta.(*providerMeta).client.WithContext(ctx)
+}
+
+// Returns the environment_id of a data source, or the default_environment_id of the provider if it isn't set.
+// The environment used is stored in the environment_id attribute.
+func getEnvironmentID(d *schema.ResourceData, meta interface{}) (string, error) {
+	if environmentID, ok := d.GetOk("environment_id"); ok {
+		return environmentID.(string), nil
+	}
+	environmentID := meta.(*providerMeta).defaultEnvironmentID
+	if environmentID == "" {
+		return "", fmt.Errorf("%q must be set, or %q on the provider", "environment_id", "default_environment_id")
+	}
+	return environmentID, d.Set("environment_id", environmentID)
+}
+
+// Sets the environment_id of a resource missing from the config to the default_environment_id of the provider.
+// Changing the default environment of the provider recreates the resources that use it.
+func customizeDiffDefaultEnvironment(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	return setProviderDefault(diff, "environment_id", "default_environment_id", meta.(*providerMeta).defaultEnvironmentID)
+}
+
+// Sets an Optional and Computed attribute missing from the config to a default of the provider
+func setProviderDefault(diff *schema.ResourceDiff, key, providerKey, defaultValue string) error {
+	config := diff.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() || !config.GetAttr(key).IsNull() {
+		return nil
+	}
+	if defaultValue == "" {
+		return fmt.Errorf("%q must be set, or %q on the provider", key, providerKey)
 	}
-	resources, err := client.GetResources(environment.ServiceConnection.ServiceCode, environment.Name)
-	if err != nil {
-		return hci.Resources{}, err
+	if diff.Get(key).(string) == defaultValue {
+		return nil
+	}
+	return diff.SetNew(key, defaultValue)
+}
+
+// Imports a resource by ID into the default_environment_id of the provider
+func importStateWithDefaultEnvironment(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if environmentID := meta.(*providerMeta).defaultEnvironmentID; environmentID != "" {
+		if err := d.Set("environment_id", environmentID); err != nil {
+			return nil, err
+		}
 	}
-	return resources.(hci.Resources), nil
+	return []*schema.ResourceData{d}, nil
 }
@@ -0,0 +1,141 @@
+package hci
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+func resourceHciAffinityGroup() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceHciAffinityGroupCreate,
+		ReadContext:   resourceHciAffinityGroupRead,
+		DeleteContext: resourceHciAffinityGroupDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		Importer: &schema.ResourceImporter{
+			StateContext: importStateWithDefaultEnvironment,
+		},
+
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
+		Schema: map[string]*schema.Schema{
+			"environment_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "ID of environment where the affinity group should be created",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the affinity group",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Description of the affinity group",
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAffinityGroupType,
+				Description:  "Type of the affinity group, either \"host affinity\" or \"host anti-affinity\"",
+			},
+			"instance_ids": {
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Ids of the instances in the affinity group",
+			},
+		},
+	}
+}
+
+func resourceHciAffinityGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	affinityGroup := hci.AffinityGroup{
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Type:        d.Get("type").(string),
+	}
+	newAffinityGroup, err := hciResources.AffinityGroups.Create(affinityGroup)
+	if err != nil {
+		return diagFromErrorf(err, "Error creating the new affinity group %s", affinityGroup.Name)
+	}
+	d.SetId(newAffinityGroup.Id)
+	return resourceHciAffinityGroupRead(ctx, d, meta)
+}
+
+func resourceHciAffinityGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	affinityGroup, err := hciResources.AffinityGroups.Get(d.Id())
+	if err != nil {
+		return diagFromError(handleNotFoundError("Affinity group", false, err, d))
+	}
+
+	if err := d.Set("name", affinityGroup.Name); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("description", affinityGroup.Description); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("type", affinityGroup.Type); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("instance_ids", affinityGroup.InstanceIds); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	return nil
+}
+
+func resourceHciAffinityGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
+
+	if rerr != nil {
+		return diagFromError(rerr)
+	}
+
+	if _, err := hciResources.AffinityGroups.Delete(d.Id()); err != nil {
+		return diagFromError(handleNotFoundError("Affinity group", true, err, d))
+	}
+
+	return nil
+}
+
+func validateAffinityGroupType(val interface{}, key string) (warns []string, errs []error) {
+	switch val.(string) {
+	case hci.AFFINITY_GROUP_TYPE_HOST_AFFINITY, hci.AFFINITY_GROUP_TYPE_HOST_ANTI_AFFINITY:
+	default:
+		errs = append(errs, fmt.Errorf("%q must be either %q or %q, got: %q", key, hci.AFFINITY_GROUP_TYPE_HOST_AFFINITY, hci.AFFINITY_GROUP_TYPE_HOST_ANTI_AFFINITY, val))
+	}
+	return
+}
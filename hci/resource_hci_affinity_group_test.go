@@ -0,0 +1,143 @@
+package hci
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccAffinityGroupCreate(t *testing.T) {
+	t.Parallel()
+
+	affinityGroupName := testAccResourceName(t)
+	instanceName := testAccResourceName(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckAffinityGroupCreateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccAffinityGroupCreate(environmentID, networkID, affinityGroupName, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckAffinityGroupCreateExists("hci_affinity_group.foobar"),
+					resource.TestCheckResourceAttr("hci_affinity_group.foobar", "type", "host anti-affinity"),
+					resource.TestCheckResourceAttr("hci_instance.foobar", "affinity_group_ids.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccAffinityGroupCreate(environment, network, name, instanceName string) string {
+	return fmt.Sprintf(`
+resource "hci_affinity_group" "foobar" {
+	environment_id = "%s"
+	name           = "%s"
+	description    = "Spread the %s instances"
+	type           = "host anti-affinity"
+}
+
+resource "hci_instance" "foobar" {
+	environment_id     = "%s"
+	network_id         = "%s"
+	name               = "%s"
+	template           = "Ubuntu 20.04.2"
+	compute_offering   = "Standard"
+	cpu_count          = 1
+	memory_in_mb       = 1024
+	affinity_group_ids = [hci_affinity_group.foobar.id]
+}`, environment, name, name, environment, network, instanceName)
+}
+
+func testAccCheckAffinityGroupCreateExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set")
+		}
+
+		if rs.Primary.Attributes["environment_id"] == "" {
+			return fmt.Errorf("Environment ID is missing")
+		}
+
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
+		if err != nil {
+			return err
+		}
+
+		found, err := resources.AffinityGroups.Get(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if found.Id != rs.Primary.ID {
+			return fmt.Errorf("Affinity group not found")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckAffinityGroupCreateDestroy(s *terraform.State) error {
+	meta := testAccProvider.Meta()
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type == "hci_affinity_group" {
+			if rs.Primary.Attributes["environment_id"] == "" {
+				return fmt.Errorf("Environment ID is missing")
+			}
+
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
+			if err != nil {
+				return err
+			}
+
+			_, err = resources.AffinityGroups.Get(rs.Primary.ID)
+			if err == nil {
+				return fmt.Errorf("Affinity group still exists")
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestUnitAffinityGroupLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_affinity_group", "affinitygroups"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(`
+resource "hci_affinity_group" "foobar" {
+	name        = "foobar"
+	type        = "host anti-affinity"
+	description = "This is a foobar affinity group"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_affinity_group.foobar", "instance_ids.#", "0"),
+					fake.checkEntity("hci_affinity_group.foobar", "affinitygroups", "type", "host anti-affinity"),
+				),
+			},
+			{
+				ResourceName:      "hci_affinity_group.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -1,10 +1,13 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	hci "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/configuration"
@@ -29,20 +32,30 @@ const (
 
 func resourceHciEnvironment() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciEnvironmentCreate,
-		Read:   resourceHciEnvironmentRead,
-		Update: resourceHciEnvironmentUpdate,
-		Delete: resourceHciEnvironmentDelete,
+		CreateContext: resourceHciEnvironmentCreate,
+		ReadContext:   resourceHciEnvironmentRead,
+		UpdateContext: resourceHciEnvironmentUpdate,
+		DeleteContext: resourceHciEnvironmentDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
+		CustomizeDiff: resourceHciEnvironmentCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			OrganizationCode: {
 				Type:        schema.TypeString,
 				ForceNew:    true,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				Description: "Organization's entry point, i.e. <entry_point>.hypertec.cloud",
 				StateFunc: func(val interface{}) string {
 					return strings.ToLower(val.(string))
@@ -50,7 +63,8 @@ func resourceHciEnvironment() *schema.Resource {
 			},
 			ServiceCode: {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "A hypertec service code",
 			},
@@ -86,11 +100,11 @@ func resourceHciEnvironment() *schema.Resource {
 	}
 }
 
-func resourceHciEnvironmentRead(d *schema.ResourceData, meta interface{}) error {
-	hciClient := meta.(*hci.HciClient)
+func resourceHciEnvironmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciClient := getClient(ctx, meta)
 	environment, err := hciClient.Environments.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("Environment", false, err, d)
+		return diagFromError(handleNotFoundError("Environment", false, err, d))
 	}
 
 	adminRoleUsers, userRoleUsers, readOnlyRoleUsers := getUsersFromRoles(environment)
@@ -99,76 +113,88 @@ func resourceHciEnvironmentRead(d *schema.ResourceData, meta interface{}) error
 	readOnlyRole, _ := d.GetOk(ReadOnlyRoleUsers)
 
 	if err := d.Set(OrganizationCode, environment.Organization.EntryPoint); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set(ServiceCode, environment.ServiceConnection.ServiceCode); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set(Name, environment.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set(Description, environment.Description); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set(AdminRoleUsers, getListOfUsersByIDOrUsername(adminRoleUsers, adminRole.(*schema.Set))); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set(UserRoleUsers, getListOfUsersByIDOrUsername(userRoleUsers, userRole.(*schema.Set))); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set(ReadOnlyRoleUsers, getListOfUsersByIDOrUsername(readOnlyRoleUsers, readOnlyRole.(*schema.Set))); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciEnvironmentCreate(d *schema.ResourceData, meta interface{}) error {
-	hciClient := meta.(*hci.HciClient)
+func resourceHciEnvironmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciClient := getClient(ctx, meta)
 
 	environment, err := getEnvironmentFromConfig(hciClient, d)
 	if err != nil {
-		return fmt.Errorf("Error parsing environment %s: %s", environment.Name, err)
+		return diagFromErrorf(err, "Error parsing environment %s", environment.Name)
 	}
 
 	newEnvironment, newErr := hciClient.Environments.Create(*environment)
 	if newErr != nil {
-		return fmt.Errorf("Error creating the new environment %s: %s", environment.Name, newErr)
+		return diagFromErrorf(newErr, "Error creating the new environment %s", environment.Name)
 	}
 
 	d.SetId(newEnvironment.Id)
 
-	return resourceHciEnvironmentRead(d, meta)
+	return resourceHciEnvironmentRead(ctx, d, meta)
 }
 
-func resourceHciEnvironmentUpdate(d *schema.ResourceData, meta interface{}) error {
-	hciClient := meta.(*hci.HciClient)
+func resourceHciEnvironmentUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciClient := getClient(ctx, meta)
 	environment, err := getEnvironmentFromConfig(hciClient, d)
 	if err != nil {
-		return fmt.Errorf("Error parsing environment %s: %s", environment.Name, err)
+		return diagFromErrorf(err, "Error parsing environment %s", environment.Name)
 	}
 	_, uerr := hciClient.Environments.Update(d.Id(), *environment)
 	if uerr != nil {
-		return fmt.Errorf("Error updating environment %s: %s", environment.Name, uerr)
+		return diagFromErrorf(uerr, "Error updating environment %s", environment.Name)
 	}
-	return resourceHciEnvironmentRead(d, meta)
+	// the name of the environment is part of the URL of its resources
+	meta.(*providerMeta).cache.invalidateEnvironment(d.Id())
+	return resourceHciEnvironmentRead(ctx, d, meta)
 }
 
-func resourceHciEnvironmentDelete(d *schema.ResourceData, meta interface{}) error {
-	hciClient := meta.(*hci.HciClient)
+func resourceHciEnvironmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciClient := getClient(ctx, meta)
 	fmt.Printf("[INFO] Destroying environment: %s\n", d.Get(Name).(string))
+	meta.(*providerMeta).cache.invalidateEnvironment(d.Id())
 	if _, err := hciClient.Environments.Delete(d.Id()); err != nil {
-		return handleNotFoundError("Environment", true, err, d)
+		return diagFromError(handleNotFoundError("Environment", true, err, d))
 	}
 	return nil
 }
 
+// Sets the organization_code and service_code missing from the config to the defaults of the provider
+func resourceHciEnvironmentCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	providerMeta := meta.(*providerMeta)
+	if err := setProviderDefault(diff, OrganizationCode, "default_organization_code", providerMeta.defaultOrganizationCode); err != nil {
+		return err
+	}
+	return setProviderDefault(diff, ServiceCode, "default_service_code", providerMeta.defaultServiceCode)
+}
+
 func getEnvironmentFromConfig(hciClient *hci.HciClient, d *schema.ResourceData) (*configuration.Environment, error) {
 	environment := configuration.Environment{}
 	environment.Name = d.Get(Name).(string)
@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccEnvironmentCreate(t *testing.T) {
 	t.Parallel()
 
-	environmentName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	environmentName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -53,7 +52,7 @@ func testAccCheckEnvironmentCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No ID is set")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
+		client := getClient(context.Background(), testAccProvider.Meta())
 
 		found, err := client.Environments.Get(rs.Primary.ID)
 		if err != nil {
@@ -70,7 +69,7 @@ func testAccCheckEnvironmentCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckEnvironmentCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	client := getClient(context.Background(), testAccProvider.Meta())
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_environment" {
@@ -83,3 +82,48 @@ func testAccCheckEnvironmentCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitEnvironmentLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_environment", "environments"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(testUnitEnvironment("foobar", "admin_role")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_environment.foobar", "admin_role.#", "1"),
+					fake.checkEntity("hci_environment.foobar", "environments", "description", "This is a foobar environment"),
+				),
+			},
+			{
+				Config: fake.config(testUnitEnvironment("renamed", "read_only_role")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_environment.foobar", "admin_role.#", "0"),
+					resource.TestCheckResourceAttr("hci_environment.foobar", "read_only_role.#", "1"),
+					fake.checkEntity("hci_environment.foobar", "environments", "name", "renamed"),
+				),
+			},
+			{
+				ResourceName:      "hci_environment.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testUnitEnvironment(name, role string) string {
+	return fmt.Sprintf(`
+resource "hci_environment" "foobar" {
+	name              = "%s"
+	description       = "This is a %s environment"
+	service_code      = "%s"
+	organization_code = "%s"
+	%s = ["%s"]
+}`, name, name, fakeServiceCode, fakeOrganizationCode, role, fakeUsername)
+}
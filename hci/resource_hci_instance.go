@@ -1,30 +1,43 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciInstance() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciInstanceCreate,
-		Read:   resourceHciInstanceRead,
-		Update: resourceHciInstanceUpdate,
-		Delete: resourceHciInstanceDelete,
+		CreateContext: resourceHciInstanceCreate,
+		ReadContext:   resourceHciInstanceRead,
+		UpdateContext: resourceHciInstanceUpdate,
+		DeleteContext: resourceHciInstanceDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: resourceHciInstanceCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where instance should be created",
 			},
@@ -53,18 +66,19 @@ func resourceHciInstance() *schema.Resource {
 			"network_id": {
 				Type:        schema.TypeString,
 				Required:    true,
-				ForceNew:    true,
-				Description: "Id of the network into which the new instance will be created",
+				Description: "Id of the network of the instance. Changing it moves the instance to the new network: the instance is rebooted, gets a new private IP, and all its port forwarding rules and load balancer rule memberships are removed. The plan doesn't list the removed rules. private_ip can't be set when changing it.",
 			},
 			"ssh_key_name": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "SSH key name to attach to the new instance. Note: Cannot be used with public key.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"public_key"},
+				Description:   "SSH key name to attach to the new instance. Note: Cannot be used with public key.",
 			},
 			"public_key": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "Public key to attach to the new instance. Note: Cannot be used with SSH key name.",
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ssh_key_name"},
+				Description:   "Public key to attach to the new instance. Note: Cannot be used with SSH key name.",
 			},
 			"user_data": {
 				Type:        schema.TypeString,
@@ -106,27 +120,97 @@ func resourceHciInstance() *schema.Resource {
 				ForceNew:    true,
 				Description: "Id of the dedicated group into which the new instance will be created",
 			},
+			"affinity_group_ids": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Ids of the affinity groups of the instance. Changing them stops and restarts a running instance. When it isn't set, the affinity groups of the instance are left unchanged, and an empty list removes them.",
+			},
+			"power_state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validatePowerState,
+				Description:  "Whether the instance should be running or stopped",
+			},
+			"reboot_trigger": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Arbitrary map of values that, when changed, reboots a running instance",
+			},
+			"destroy_options": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "What to do with the instance and its attached resources when it is destroyed",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"purge_immediately": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: "Purge the instance immediately. If false, the instance can be recovered until it is purged.",
+						},
+						"delete_snapshots": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Delete the snapshots of the instance's volumes",
+						},
+						"release_public_ips": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Release the public IPs forwarding traffic to the instance",
+						},
+						"delete_volumes": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Delete the data volumes attached to the instance",
+						},
+					},
+				},
+			},
+			"recover_destroyed": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "On creation, recover a destroyed but not yet purged instance with the same name, template, compute offering, network and zone instead of creating a new one. The other attributes of the recovered instance are those of the destroyed instance. Fails if several destroyed instances match",
+			},
+			"zone": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Name or id of the zone in which the instance should be created. The template must be available in this zone.",
+				StateFunc: func(val interface{}) string {
+					return strings.ToLower(val.(string))
+				},
+			},
 		},
 	}
 }
 
-func resourceHciInstanceCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciInstanceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	computeOfferingID, cerr := retrieveComputeOfferingID(&hciResources, d.Get("compute_offering").(string))
 
 	if cerr != nil {
-		return cerr
+		return diagFromError(cerr)
 	}
 
 	templateID, terr := retrieveTemplateID(&hciResources, d.Get("template").(string))
 
 	if terr != nil {
-		return terr
+		return diagFromError(terr)
 	}
 
 	instanceToCreate := hci.Instance{Name: d.Get("name").(string),
@@ -160,9 +244,9 @@ func resourceHciInstanceCreate(d *schema.ResourceData, meta interface{}) error {
 
 	computeOffering, cerr := hciResources.ComputeOfferings.Get(computeOfferingID)
 	if cerr != nil {
-		return cerr
+		return diagFromError(cerr)
 	} else if !computeOffering.Custom && hasCustomFields {
-		return fmt.Errorf("Cannot have a CPU count or memory in MB because \"%s\" isn't a custom compute offering", computeOffering.Name)
+		return diag.Errorf("Cannot have a CPU count or memory in MB because \"%s\" isn't a custom compute offering", computeOffering.Name)
 	}
 
 	if rootVolumeSizeInGb, ok := d.GetOk("root_volume_size_in_gb"); ok {
@@ -173,9 +257,40 @@ func resourceHciInstanceCreate(d *schema.ResourceData, meta interface{}) error {
 		instanceToCreate.DedicatedGroupId = dedicatedGroupID.(string)
 	}
 
+	if affinityGroupIDs, ok := d.GetOk("affinity_group_ids"); ok {
+		for _, id := range affinityGroupIDs.(*schema.Set).List() {
+			instanceToCreate.AffinityGroupIds = append(instanceToCreate.AffinityGroupIds, id.(string))
+		}
+	}
+
+	if zone, ok := d.GetOk("zone"); ok {
+		zoneID, zerr := retrieveZoneID(&hciResources, zone.(string))
+		if zerr != nil {
+			return diagFromError(zerr)
+		}
+		instanceToCreate.ZoneId = zoneID
+	}
+
+	if d.Get("recover_destroyed").(bool) {
+		recovered, err := recoverDestroyedInstance(hciResources, instanceToCreate)
+		if err != nil {
+			return diagFromError(err)
+		}
+		if recovered != nil {
+			d.SetId(recovered.Id)
+			// a recovered instance is stopped
+			if d.Get("power_state").(string) != powerStateStopped {
+				if _, err := hciResources.Instances.Start(recovered.Id); err != nil {
+					return diagFromErrorf(err, "Error starting the recovered instance %s", instanceToCreate.Name)
+				}
+			}
+			return resourceHciInstanceRead(ctx, d, meta)
+		}
+	}
+
 	newInstance, err := hciResources.Instances.Create(instanceToCreate)
 	if err != nil {
-		return fmt.Errorf("Error creating the new instance %s: %s", instanceToCreate.Name, err)
+		return diagFromErrorf(err, "Error creating the new instance %s", instanceToCreate.Name)
 	}
 
 	d.SetId(newInstance.Id)
@@ -185,62 +300,88 @@ func resourceHciInstanceCreate(d *schema.ResourceData, meta interface{}) error {
 		"password": newInstance.Password,
 	})
 
-	return resourceHciInstanceRead(d, meta)
+	if d.Get("power_state").(string) == powerStateStopped {
+		if _, err := hciResources.Instances.Stop(newInstance.Id); err != nil {
+			return diagFromErrorf(err, "Error stopping the new instance %s", instanceToCreate.Name)
+		}
+	}
+
+	return resourceHciInstanceRead(ctx, d, meta)
 }
 
-func resourceHciInstanceRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciInstanceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	// Get the virtual machine details
 	instance, err := hciResources.Instances.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("Instance", false, err, d)
+		return diagFromError(handleNotFoundError("Instance", false, err, d))
 	}
 	// Update the config
 	if err := d.Set("name", instance.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := setValueOrID(d, "template", strings.ToLower(instance.TemplateName), instance.TemplateId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := setValueOrID(d, "compute_offering", strings.ToLower(instance.ComputeOfferingName), instance.ComputeOfferingId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("network_id", instance.NetworkId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("private_ip_id", instance.IpAddressId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("private_ip", instance.IpAddress); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	dID, dIDErr := getDedicatedGroupID(hciResources, instance)
 	if dIDErr != nil {
-		return dIDErr
+		return diagFromError(dIDErr)
 	}
 
 	if err := d.Set("dedicated_group_id", dID); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := d.Set("power_state", getPowerState(instance)); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	// the dedicated group is managed through dedicated_group_id
+	affinityGroupIDs := []string{}
+	for _, id := range instance.AffinityGroupIds {
+		if !strings.EqualFold(id, dID) {
+			affinityGroupIDs = append(affinityGroupIDs, id)
+		}
+	}
+
+	if err := d.Set("affinity_group_ids", affinityGroupIDs); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := setValueOrID(d, "zone", strings.ToLower(instance.ZoneName), instance.ZoneId); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciInstanceUpdate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciInstanceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	d.Partial(true)
 
@@ -249,7 +390,7 @@ func resourceHciInstanceUpdate(d *schema.ResourceData, meta interface{}) error {
 		log.Printf("[DEBUG] Compute offering has changed for %s, changing compute offering...", newComputeOffering)
 		newComputeOfferingID, ferr := retrieveComputeOfferingID(&hciResources, newComputeOffering)
 		if ferr != nil {
-			return ferr
+			return diagFromError(ferr)
 		}
 		instanceToUpdate := hci.Instance{Id: d.Id(),
 			ComputeOfferingId: newComputeOfferingID,
@@ -267,14 +408,14 @@ func resourceHciInstanceUpdate(d *schema.ResourceData, meta interface{}) error {
 
 		computeOffering, cerr := hciResources.ComputeOfferings.Get(newComputeOfferingID)
 		if cerr != nil {
-			return cerr
+			return diagFromError(cerr)
 		} else if !computeOffering.Custom && hasCustomFields {
-			return fmt.Errorf("Cannot have a CPU count or memory in MB because \"%s\" isn't a custom compute offering", computeOffering.Name)
+			return diag.Errorf("Cannot have a CPU count or memory in MB because \"%s\" isn't a custom compute offering", computeOffering.Name)
 		}
 
 		_, err := hciResources.Instances.ChangeComputeOffering(instanceToUpdate)
 		if err != nil {
-			return err
+			return diagFromError(err)
 		}
 	}
 
@@ -283,28 +424,102 @@ func resourceHciInstanceUpdate(d *schema.ResourceData, meta interface{}) error {
 		log.Printf("[DEBUG] SSH key name has changed for %s, associating new SSH key...", sshKeyName)
 		_, err := hciResources.Instances.AssociateSSHKey(d.Id(), sshKeyName)
 		if err != nil {
-			return err
+			return diagFromError(err)
 		}
 	}
 
-	if d.HasChange("private_ip") {
-		return fmt.Errorf("Cannot update the private IP of an instance")
+	var diags diag.Diagnostics
+	moved := false
+	if d.HasChange("network_id") {
+		newNetworkID := d.Get("network_id").(string)
+		log.Printf("[DEBUG] Network has changed for %s, moving instance to network %s...", d.Id(), newNetworkID)
+		if _, err := hciResources.Instances.ChangeNetwork(d.Id(), newNetworkID); err != nil {
+			return diagFromError(err)
+		}
+		moved = true
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "Instance moved to another network",
+			Detail:        fmt.Sprintf("Instance %s was moved to network %s. Its port forwarding rules and load balancer rule memberships were removed.", d.Get("name").(string), newNetworkID),
+			AttributePath: cty.GetAttrPath("network_id"),
+		})
+	}
+
+	if d.HasChange("private_ip") && !d.HasChange("network_id") {
+		return diag.Errorf("Cannot update the private IP of an instance")
+	}
+
+	if d.HasChange("affinity_group_ids") {
+		log.Printf("[DEBUG] Affinity groups have changed for %s, changing affinity groups...", d.Id())
+		if err := changeAffinityGroups(hciResources, d); err != nil {
+			return keepNetworkMove(hciResources, d, moved, append(diags, diagFromError(err)...))
+		}
+	}
+
+	if d.HasChange("power_state") {
+		powerState := d.Get("power_state").(string)
+		log.Printf("[DEBUG] Power state has changed for %s, changing power state to %s...", d.Id(), powerState)
+		if err := changePowerState(hciResources, d.Id(), powerState); err != nil {
+			return keepNetworkMove(hciResources, d, moved, append(diags, diagFromError(err)...))
+		}
+	} else if d.HasChange("reboot_trigger") && d.Get("power_state").(string) == powerStateRunning {
+		log.Printf("[DEBUG] Reboot trigger has changed for %s, rebooting...", d.Id())
+		if _, err := hciResources.Instances.Reboot(d.Id()); err != nil {
+			return keepNetworkMove(hciResources, d, moved, append(diags, diagFromError(err)...))
+		}
 	}
 
 	d.Partial(false)
 
-	return nil
+	return append(diags, resourceHciInstanceRead(ctx, d, meta)...)
+}
+
+// keepNetworkMove saves the move of the instance to its new network in the state when a later step of the update fails.
+// The partial update would otherwise keep the previous network and private IP in the state, so the other changes are
+// reverted to their previous value and the network and private IP are read from the API.
+func keepNetworkMove(hciResources hci.Resources, d *schema.ResourceData, moved bool, diags diag.Diagnostics) diag.Diagnostics {
+	if !moved {
+		return diags
+	}
+	for key := range resourceHciInstance().Schema {
+		if key != "network_id" && d.HasChange(key) {
+			old, _ := d.GetChange(key)
+			if err := d.Set(key, old); err != nil {
+				return append(diags, diag.FromErr(err)...)
+			}
+		}
+	}
+	instance, err := hciResources.Instances.Get(d.Id())
+	if err != nil {
+		return append(diags, diagFromError(err)...)
+	}
+	if err := d.Set("network_id", instance.NetworkId); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if err := d.Set("private_ip", instance.IpAddress); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if err := d.Set("private_ip_id", instance.IpAddressId); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	d.Partial(false)
+	return diags
 }
 
-func resourceHciInstanceDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciInstanceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
-	fmt.Printf("[INFO] Destroying instance: %s\n", d.Get("name").(string))
-	if _, err := hciResources.Instances.Destroy(d.Id(), true); err != nil {
-		return handleNotFoundError("Instance", true, err, d)
+	destroyOptions, err := getDestroyOptions(hciResources, d)
+	if err != nil {
+		return diagFromError(handleNotFoundError("Instance", true, err, d))
+	}
+
+	log.Printf("[INFO] Destroying instance: %s", d.Get("name").(string))
+	if _, err := hciResources.Instances.DestroyWithOptions(d.Id(), destroyOptions); err != nil {
+		return diagFromError(handleNotFoundError("Instance", true, err, d))
 	}
 
 	return nil
@@ -314,45 +529,370 @@ func retrieveComputeOfferingID(hciRes *hci.Resources, name string) (id string, e
 	if isID(name) {
 		return name, nil
 	}
-
-	computeOfferings, err := hciRes.ComputeOfferings.List()
+	offering, err := retrieveComputeOffering(hciRes, name)
 	if err != nil {
 		return "", err
 	}
-	for _, offering := range computeOfferings {
+	return offering.Id, nil
+}
 
+func resourceHciInstanceCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if err := customizeDiffDefaultEnvironment(ctx, diff, meta); err != nil {
+		return err
+	}
+	if err := validatePrivateIPChange(diff); err != nil {
+		return err
+	}
+	if err := planNetworkChange(diff); err != nil {
+		return err
+	}
+	if err := validateInstanceSizing(ctx, diff, meta); err != nil {
+		return err
+	}
+	return validateInstanceZone(ctx, diff, meta)
+}
+
+// planNetworkChange marks the private IP of an instance moved to another network as unknown, since the new network assigns it
+func planNetworkChange(diff *schema.ResourceDiff) error {
+	if diff.Id() == "" || !diff.HasChange("network_id") {
+		return nil
+	}
+	if err := diff.SetNewComputed("private_ip"); err != nil {
+		return err
+	}
+	return diff.SetNewComputed("private_ip_id")
+}
+
+// validatePrivateIPChange rejects changing the private IP of an existing instance, which the API doesn't support. When the
+// instance is moved to another network, the new network assigns its private IP, so the private IP can't be chosen either.
+func validatePrivateIPChange(diff *schema.ResourceDiff) error {
+	config := diff.GetRawConfig()
+	if diff.Id() == "" || config.IsNull() || !config.IsKnown() {
+		return nil
+	}
+	privateIP := config.GetAttr("private_ip")
+	if privateIP.IsNull() || !privateIP.IsKnown() {
+		return nil
+	}
+	if diff.HasChange("network_id") {
+		return fmt.Errorf("private_ip can't be set when changing network_id, the new network assigns the private IP of the instance")
+	}
+	if diff.HasChange("private_ip") {
+		return fmt.Errorf("Cannot update the private IP of an instance")
+	}
+	return nil
+}
+
+// validateInstanceSizing checks at plan time that the compute offering and the template allow the CPU count, memory,
+// root volume size and SSH key of the config, rather than failing during apply.
+func validateInstanceSizing(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	config := diff.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() || !diff.NewValueKnown("environment_id") {
+		return nil
+	}
+	// an update only checks the compute offering and the template when the fields they allow change
+	checkComputeOffering := diff.Id() == "" || hasAnyChange(diff, "compute_offering", "cpu_count", "memory_in_mb")
+	checkTemplate := diff.Id() == "" || hasAnyChange(diff, "template", "root_volume_size_in_gb", "ssh_key_name", "public_key")
+	if !checkComputeOffering && !checkTemplate {
+		return nil
+	}
+	isSet := func(key string) bool {
+		return !config.GetAttr(key).IsNull()
+	}
+
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, diff.Get("environment_id").(string))
+
+	if rerr != nil {
+		return rerr
+	}
+
+	if checkComputeOffering && diff.NewValueKnown("compute_offering") {
+		computeOffering, err := retrieveComputeOffering(&hciResources, diff.Get("compute_offering").(string))
+		if err != nil {
+			return err
+		}
+		if !computeOffering.Custom && (isSet("cpu_count") || isSet("memory_in_mb")) {
+			return fmt.Errorf("Cannot have a CPU count or memory in MB because \"%s\" isn't a custom compute offering", computeOffering.Name)
+		}
+		if computeOffering.Custom && (!isSet("cpu_count") || !isSet("memory_in_mb")) {
+			return fmt.Errorf("A CPU count and memory in MB are required because \"%s\" is a custom compute offering", computeOffering.Name)
+		}
+	}
+
+	if checkTemplate && diff.NewValueKnown("template") {
+		template, err := retrieveTemplate(&hciResources, diff.Get("template").(string))
+		if err != nil {
+			return err
+		}
+		if !template.Resizable && isSet("root_volume_size_in_gb") {
+			return fmt.Errorf("Cannot have a root volume size because template \"%s\" isn't resizable", template.Name)
+		}
+		if !template.SSHKeyEnabled && (isSet("ssh_key_name") || isSet("public_key")) {
+			return fmt.Errorf("Cannot have an SSH key name or public key because template \"%s\" doesn't support SSH keys", template.Name)
+		}
+	}
+	return nil
+}
+
+func hasAnyChange(diff *schema.ResourceDiff, keys ...string) bool {
+	for _, key := range keys {
+		if diff.HasChange(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInstanceZone checks at plan time that the template is available in the chosen zone.
+func validateInstanceZone(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	zone, ok := diff.GetOk("zone")
+	if !ok || !diff.NewValueKnown("zone") || !diff.NewValueKnown("template") || !diff.NewValueKnown("environment_id") {
+		return nil
+	}
+	if diff.Id() != "" && !diff.HasChange("zone") && !diff.HasChange("template") {
+		return nil
+	}
+
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, diff.Get("environment_id").(string))
+
+	if rerr != nil {
+		return rerr
+	}
+
+	zoneID, err := retrieveZoneID(&hciResources, zone.(string))
+	if err != nil {
+		return err
+	}
+
+	template, err := retrieveTemplate(&hciResources, diff.Get("template").(string))
+	if err != nil {
+		return err
+	}
+
+	if !templateAvailableInZone(*template, zoneID) {
+		return fmt.Errorf("Template %s is not available in zone %s", template.Name, zone)
+	}
+	return nil
+}
+
+func retrieveComputeOffering(hciRes *hci.Resources, name string) (computeOffering *hci.ComputeOffering, err error) {
+	if isID(name) {
+		return hciRes.ComputeOfferings.Get(name)
+	}
+	offerings, err := hciRes.ComputeOfferings.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, offering := range offerings {
 		if strings.EqualFold(offering.Name, name) {
 			log.Printf("Found compute offering: %+v", offering)
-			return offering.Id, nil
+			return &offering, nil
 		}
 	}
+	return nil, fmt.Errorf("Compute offering with name %s not found", name)
+}
 
-	return "", fmt.Errorf("Compute offering with name %s not found", name)
+func retrieveTemplate(hciRes *hci.Resources, name string) (template *hci.Template, err error) {
+	if isID(name) {
+		return hciRes.Templates.Get(name)
+	}
+	templates, err := hciRes.Templates.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, template := range templates {
+		if strings.EqualFold(template.Name, name) {
+			log.Printf("Found template: %+v", template)
+			return &template, nil
+		}
+	}
+	return nil, fmt.Errorf("Template with name %s not found", name)
 }
 
 func retrieveTemplateID(hciRes *hci.Resources, name string) (id string, err error) {
 	if isID(name) {
 		return name, nil
 	}
-
-	templates, err := hciRes.Templates.List()
+	template, err := retrieveTemplate(hciRes, name)
 	if err != nil {
 		return "", err
 	}
-	for _, template := range templates {
+	return template.ID, nil
+}
 
-		if strings.EqualFold(template.Name, name) {
-			log.Printf("Found template: %+v", template)
-			return template.ID, nil
+const (
+	powerStateRunning = "running"
+	powerStateStopped = "stopped"
+)
+
+func validatePowerState(val interface{}, key string) (warns []string, errs []error) {
+	switch val.(string) {
+	case powerStateRunning, powerStateStopped:
+	default:
+		errs = append(errs, fmt.Errorf("%q must be either %q or %q, got: %q", key, powerStateRunning, powerStateStopped, val))
+	}
+	return
+}
+
+// getPowerState maps the state of the instance to a power state. Transitional states are returned as is.
+func getPowerState(instance *hci.Instance) string {
+	if instance.IsRunning() {
+		return powerStateRunning
+	}
+	if instance.IsStopped() {
+		return powerStateStopped
+	}
+	return strings.ToLower(instance.State)
+}
+
+// changePowerState starts or stops the instance, unless it's already in the wanted power state.
+func changePowerState(hciRes hci.Resources, id string, powerState string) error {
+	instance, err := hciRes.Instances.Get(id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case powerState == powerStateRunning && !instance.IsRunning():
+		if _, err := hciRes.Instances.Start(id); err != nil {
+			return fmt.Errorf("Error starting instance %s: %w", id, err)
+		}
+	case powerState == powerStateStopped && !instance.IsStopped():
+		if _, err := hciRes.Instances.Stop(id); err != nil {
+			return fmt.Errorf("Error stopping instance %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// getDestroyOptions builds the destroy options from the destroy_options block. Without the block, the instance is purged immediately.
+func getDestroyOptions(hciRes hci.Resources, d *schema.ResourceData) (hci.DestroyOptions, error) {
+	destroyOptions := hci.DestroyOptions{PurgeImmediately: true}
+
+	options, ok := d.GetOk("destroy_options")
+	if !ok || len(options.([]interface{})) == 0 || options.([]interface{})[0] == nil {
+		return destroyOptions, nil
+	}
+	block := options.([]interface{})[0].(map[string]interface{})
+
+	destroyOptions.PurgeImmediately = block["purge_immediately"].(bool)
+	destroyOptions.DeleteSnapshots = block["delete_snapshots"].(bool)
+
+	if block["release_public_ips"].(bool) {
+		instance, err := hciRes.Instances.Get(d.Id())
+		if err != nil {
+			return destroyOptions, err
+		}
+		for _, publicIP := range instance.PublicIps {
+			destroyOptions.PublicIpIdsToRelease = append(destroyOptions.PublicIpIdsToRelease, publicIP.Id)
+		}
+	}
+
+	if block["delete_volumes"].(bool) {
+		volumes, err := hciRes.Volumes.ListWithOptions(map[string]string{
+			"instanceId": d.Id(),
+			"type":       hci.VOLUME_TYPE_DATA,
+		})
+		if err != nil {
+			return destroyOptions, err
+		}
+		for _, volume := range volumes {
+			if strings.EqualFold(volume.InstanceId, d.Id()) && volume.Type == hci.VOLUME_TYPE_DATA {
+				destroyOptions.VolumeIdsToDelete = append(destroyOptions.VolumeIdsToDelete, volume.Id)
+			}
 		}
 	}
 
-	return "", fmt.Errorf("Template with name %s not found", name)
+	return destroyOptions, nil
+}
+
+// recoverDestroyedInstance recovers the destroyed instance with the name, template, compute offering, network and zone
+// of the instance to create, if there's one. The zone only has to match when the instance to create sets it. It relies
+// on the API listing destroyed instances until they are purged, which TestAccInstanceRecoverDestroyed checks.
+func recoverDestroyedInstance(hciRes hci.Resources, instanceToCreate hci.Instance) (*hci.Instance, error) {
+	instances, err := hciRes.Instances.List()
+	if err != nil {
+		return nil, err
+	}
+	candidates := []hci.Instance{}
+	for _, instance := range instances {
+		if !instance.IsDestroyed() || !strings.EqualFold(instance.Name, instanceToCreate.Name) {
+			continue
+		}
+		if !strings.EqualFold(instance.TemplateId, instanceToCreate.TemplateId) ||
+			!strings.EqualFold(instance.ComputeOfferingId, instanceToCreate.ComputeOfferingId) ||
+			!strings.EqualFold(instance.NetworkId, instanceToCreate.NetworkId) ||
+			instanceToCreate.ZoneId != "" && !strings.EqualFold(instance.ZoneId, instanceToCreate.ZoneId) {
+			log.Printf("[INFO] Not recovering destroyed instance %s (%s), its template, compute offering, network or zone differs", instance.Name, instance.Id)
+			continue
+		}
+		candidates = append(candidates, instance)
+	}
+
+	switch len(candidates) {
+	case 0:
+		log.Printf("[INFO] No destroyed instance %s to recover, creating a new one", instanceToCreate.Name)
+		return nil, nil
+	case 1:
+		instance := candidates[0]
+		log.Printf("[INFO] Recovering destroyed instance %s (%s)", instance.Name, instance.Id)
+		if _, err := hciRes.Instances.Recover(instance.Id); err != nil {
+			return nil, fmt.Errorf("Error recovering the destroyed instance %s: %w", instance.Name, err)
+		}
+		return &instance, nil
+	default:
+		ids := []string{}
+		for _, instance := range candidates {
+			ids = append(ids, instance.Id)
+		}
+		return nil, fmt.Errorf("Several destroyed instances named %s match, recover one of them or purge the others: %s",
+			instanceToCreate.Name, strings.Join(ids, ", "))
+	}
+}
+
+// changeAffinityGroups replaces the affinity groups of the instance, stopping it during the change if it is running.
+func changeAffinityGroups(hciRes hci.Resources, d *schema.ResourceData) error {
+	instance, err := hciRes.Instances.Get(d.Id())
+	if err != nil {
+		return err
+	}
+
+	affinityGroupIDs := []string{}
+	for _, id := range d.Get("affinity_group_ids").(*schema.Set).List() {
+		affinityGroupIDs = append(affinityGroupIDs, id.(string))
+	}
+	// keep the instance in its dedicated group
+	if dID := d.Get("dedicated_group_id").(string); dID != "" {
+		affinityGroupIDs = append(affinityGroupIDs, dID)
+	}
+
+	wasRunning := instance.IsRunning()
+	if wasRunning {
+		if _, err := hciRes.Instances.Stop(d.Id()); err != nil {
+			return fmt.Errorf("Error stopping instance %s to change its affinity groups: %w", d.Id(), err)
+		}
+	}
+
+	if _, err := hciRes.Instances.ChangeAffinityGroups(d.Id(), affinityGroupIDs); err != nil {
+		// don't leave a running instance stopped
+		if wasRunning {
+			if _, startErr := hciRes.Instances.Start(d.Id()); startErr != nil {
+				log.Printf("[WARN] Error starting instance %s after failing to change its affinity groups: %s", d.Id(), startErr)
+			}
+		}
+		return err
+	}
+
+	if wasRunning && d.Get("power_state").(string) != powerStateStopped {
+		if _, err := hciRes.Instances.Start(d.Id()); err != nil {
+			return fmt.Errorf("Error starting instance %s after changing its affinity groups: %w", d.Id(), err)
+		}
+	}
+	return nil
 }
 
 func getDedicatedGroupID(hciRes hci.Resources, instance *hci.Instance) (string, error) {
 	dedicatedGroups, err := hciRes.AffinityGroups.ListWithOptions(map[string]string{
-		"type": "ExplicitDedication",
+		"type": hci.AFFINITY_GROUP_TYPE_EXPLICIT_DEDICATION,
 	})
 	if err != nil {
 		return "", err
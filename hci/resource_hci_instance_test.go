@@ -1,13 +1,16 @@
 package hci
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
+	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 const hciInstance = "hci_instance"
@@ -15,7 +18,7 @@ const hciInstance = "hci_instance"
 func TestAccInstanceCreateBasic(t *testing.T) {
 	t.Parallel()
 
-	instanceName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	instanceName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -36,7 +39,7 @@ func TestAccInstanceCreateDataDrive(t *testing.T) {
 	t.Parallel()
 
 	networkID := "719af2c3-2da8-474f-b03e-63fce6e1a827"
-	instanceName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	instanceName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -53,6 +56,147 @@ func TestAccInstanceCreateDataDrive(t *testing.T) {
 	})
 }
 
+func TestAccInstanceCreateInZone(t *testing.T) {
+	t.Parallel()
+
+	instanceName := testAccResourceName(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckInstanceCreateBasicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceCreateInZone(environmentID, networkID, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckInstanceCreateBasicExists("hci_instance.foobar"),
+					resource.TestCheckResourceAttrPair("hci_instance.foobar", "zone", "data.hci_zones.foobar", "ids.0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstanceChangeNetwork(t *testing.T) {
+	t.Parallel()
+
+	otherNetworkID := "719af2c3-2da8-474f-b03e-63fce6e1a827"
+	instanceName := testAccResourceName(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckInstanceCreateBasicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceCreateBasic(environmentID, networkID, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckInstanceCreateBasicExists("hci_instance.foobar"),
+					resource.TestCheckResourceAttr("hci_instance.foobar", "network_id", networkID),
+				),
+			},
+			{
+				Config: testAccInstanceCreateBasic(environmentID, otherNetworkID, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckInstanceCreateBasicExists("hci_instance.foobar"),
+					resource.TestCheckResourceAttr("hci_instance.foobar", "network_id", otherNetworkID),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstanceRecoverDestroyed(t *testing.T) {
+	t.Parallel()
+
+	instanceName := testAccResourceName(t)
+	var instanceID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckInstanceCreateBasicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceSoftDestroy(environmentID, networkID, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckInstanceCreateBasicExists("hci_instance.foobar"),
+					func(s *terraform.State) error {
+						instanceID = s.RootModule().Resources["hci_instance.foobar"].Primary.ID
+						return nil
+					},
+				),
+			},
+			{
+				Config: testAccDataSourceZones(environmentID),
+				Check: func(s *terraform.State) error {
+					// recovering relies on the API listing the destroyed instances
+					resources, err := getResourcesForEnvironmentID(context.Background(), testAccProvider.Meta(), environmentID)
+					if err != nil {
+						return err
+					}
+					instances, err := resources.Instances.List()
+					if err != nil {
+						return err
+					}
+					for _, instance := range instances {
+						if instance.Id == instanceID && instance.IsDestroyed() {
+							return nil
+						}
+					}
+					return fmt.Errorf("Expected destroyed instance %s to be listed", instanceID)
+				},
+			},
+			{
+				Config: testAccInstanceRecoverDestroyed(environmentID, networkID, instanceName),
+				Check: resource.ComposeTestCheckFunc(
+					func(s *terraform.State) error {
+						if id := s.RootModule().Resources["hci_instance.foobar"].Primary.ID; id != instanceID {
+							return fmt.Errorf("Expected instance %s to be recovered, got %s", instanceID, id)
+						}
+						return nil
+					},
+					resource.TestCheckResourceAttr("hci_instance.foobar", "power_state", "running"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccInstancePowerState(t *testing.T) {
+	t.Parallel()
+
+	instanceName := testAccResourceName(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckInstanceCreateBasicDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstancePowerState(environmentID, networkID, instanceName, "stopped", "1"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckInstanceCreateBasicExists("hci_instance.foobar"),
+					resource.TestCheckResourceAttr("hci_instance.foobar", "power_state", "stopped"),
+				),
+			},
+			{
+				Config: testAccInstancePowerState(environmentID, networkID, instanceName, "running", "1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_instance.foobar", "power_state", "running"),
+				),
+			},
+			{
+				Config: testAccInstancePowerState(environmentID, networkID, instanceName, "running", "2"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_instance.foobar", "power_state", "running"),
+					resource.TestCheckResourceAttr("hci_instance.foobar", "reboot_trigger.config", "2"),
+				),
+			},
+		},
+	})
+}
+
 func testAccInstanceCreateBasic(environment, network, name string) string {
 	return fmt.Sprintf(`
 resource %s "foobar" {
@@ -66,6 +210,72 @@ resource %s "foobar" {
 }`, hciInstance, environment, network, name)
 }
 
+func testAccInstanceCreateInZone(environment, network, name string) string {
+	return fmt.Sprintf(`
+data "hci_zones" "foobar" {
+	environment_id = "%s"
+}
+
+resource %s "foobar" {
+	environment_id   = "%s"
+	network_id       = "%s"
+	name             = "%s"
+	template         = "Ubuntu 20.04.2"
+	compute_offering = "Standard"
+	cpu_count        = 1
+	memory_in_mb     = 1024
+	zone             = data.hci_zones.foobar.ids[0]
+}`, environment, hciInstance, environment, network, name)
+}
+
+func testAccInstancePowerState(environment, network, name, powerState, trigger string) string {
+	return fmt.Sprintf(`
+resource %s "foobar" {
+	environment_id   = "%s"
+	network_id       = "%s"
+	name             = "%s"
+	template         = "Ubuntu 20.04.2"
+	compute_offering = "Standard"
+	cpu_count        = 1
+	memory_in_mb     = 1024
+	power_state      = "%s"
+	reboot_trigger = {
+		config = "%s"
+	}
+}`, hciInstance, environment, network, name, powerState, trigger)
+}
+
+func testAccInstanceSoftDestroy(environment, network, name string) string {
+	return fmt.Sprintf(`
+resource %s "foobar" {
+	environment_id   = "%s"
+	network_id       = "%s"
+	name             = "%s"
+	template         = "Ubuntu 20.04.2"
+	compute_offering = "Standard"
+	cpu_count        = 1
+	memory_in_mb     = 1024
+
+	destroy_options {
+		purge_immediately = false
+	}
+}`, hciInstance, environment, network, name)
+}
+
+func testAccInstanceRecoverDestroyed(environment, network, name string) string {
+	return fmt.Sprintf(`
+resource %s "foobar" {
+	environment_id    = "%s"
+	network_id        = "%s"
+	name              = "%s"
+	template          = "Ubuntu 20.04.2"
+	compute_offering  = "Standard"
+	cpu_count         = 1
+	memory_in_mb      = 1024
+	recover_destroyed = true
+}`, hciInstance, environment, network, name)
+}
+
 func testAccInstanceCreateDataDrive(environment, network, name string) string {
 	return fmt.Sprintf(`
 resource %s "foobar" {
@@ -101,8 +311,8 @@ func testAccCheckInstanceCreateBasicExists(name string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -135,8 +345,8 @@ func testAccCheckInstanceCreateDataDriveExists(name string) resource.TestCheckFu
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -155,7 +365,7 @@ func testAccCheckInstanceCreateDataDriveExists(name string) resource.TestCheckFu
 }
 
 func testAccCheckInstanceCreateBasicDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == hciInstance {
@@ -163,7 +373,7 @@ func testAccCheckInstanceCreateBasicDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -179,7 +389,7 @@ func testAccCheckInstanceCreateBasicDestroy(s *terraform.State) error {
 }
 
 func testAccCheckInstanceCreateDataDriveDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == hciInstance {
@@ -187,7 +397,7 @@ func testAccCheckInstanceCreateDataDriveDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -201,3 +411,478 @@ func testAccCheckInstanceCreateDataDriveDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitInstanceLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy(hciInstance, "instances"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(testUnitInstance("1vCPU.1GB", "running")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_instance.foobar", "zone", "qc-1"),
+					resource.TestCheckResourceAttrSet("hci_instance.foobar", "private_ip"),
+					fake.checkEntity("hci_instance.foobar", "instances", "state", "Running"),
+					fake.checkEntity("hci_instance.foobar", "instances", "cpuCount", 1),
+				),
+			},
+			{
+				Config: fake.config(testUnitInstance("2vCPU.4GB", "stopped")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_instance.foobar", "power_state", "stopped"),
+					fake.checkEntity("hci_instance.foobar", "instances", "state", "Stopped"),
+					fake.checkEntity("hci_instance.foobar", "instances", "cpuCount", 2),
+					fake.checkEntity("hci_instance.foobar", "instances", "memoryInMB", 4096),
+				),
+			},
+			{
+				ResourceName:            "hci_instance.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"recover_destroyed"},
+			},
+		},
+	})
+}
+
+func testUnitInstance(computeOffering, powerState string) string {
+	return fmt.Sprintf(fakeNetworkConfig+`
+resource "hci_instance" "foobar" {
+	name             = "foobar"
+	template         = "Ubuntu 20.04"
+	compute_offering = "%s"
+	network_id       = hci_network.fake.id
+	power_state      = "%s"
+}`, computeOffering, powerState)
+}
+
+func TestUnitInstanceSizingValidation(t *testing.T) {
+	fake := newFakeAPI(t)
+	fake.add("templates", fakeEntity{"name": "Appliance", "ready": true, "resizable": false, "sshKeyEnabled": false})
+	meta := fake.meta(t, fakeAPIKey)
+
+	for _, test := range []struct {
+		name       string
+		attributes map[string]cty.Value
+		err        string
+	}{
+		{
+			name:       "non-custom offering",
+			attributes: map[string]cty.Value{"compute_offering": cty.StringVal(fakeComputeOffering)},
+		},
+		{
+			name: "custom offering",
+			attributes: map[string]cty.Value{
+				"compute_offering": cty.StringVal(fakeCustomComputeOffering),
+				"cpu_count":        cty.NumberIntVal(2),
+				"memory_in_mb":     cty.NumberIntVal(2048),
+			},
+		},
+		{
+			name: "CPU count on a non-custom offering",
+			attributes: map[string]cty.Value{
+				"compute_offering": cty.StringVal(fakeComputeOffering),
+				"cpu_count":        cty.NumberIntVal(2),
+			},
+			err: "isn't a custom compute offering",
+		},
+		{
+			name: "memory missing on a custom offering",
+			attributes: map[string]cty.Value{
+				"compute_offering": cty.StringVal(fakeCustomComputeOffering),
+				"cpu_count":        cty.NumberIntVal(2),
+			},
+			err: "is a custom compute offering",
+		},
+		{
+			name: "unknown CPU count on a custom offering",
+			attributes: map[string]cty.Value{
+				"compute_offering": cty.StringVal(fakeCustomComputeOffering),
+				"cpu_count":        cty.UnknownVal(cty.Number),
+				"memory_in_mb":     cty.NumberIntVal(2048),
+			},
+		},
+		{
+			name: "root volume size of a resizable template",
+			attributes: map[string]cty.Value{
+				"compute_offering":       cty.StringVal(fakeComputeOffering),
+				"root_volume_size_in_gb": cty.NumberIntVal(50),
+				"ssh_key_name":           cty.StringVal("foobar"),
+			},
+		},
+		{
+			name: "root volume size of a non-resizable template",
+			attributes: map[string]cty.Value{
+				"template":               cty.StringVal("Appliance"),
+				"compute_offering":       cty.StringVal(fakeComputeOffering),
+				"root_volume_size_in_gb": cty.NumberIntVal(50),
+			},
+			err: "isn't resizable",
+		},
+		{
+			name: "public key on a template without SSH keys",
+			attributes: map[string]cty.Value{
+				"template":         cty.StringVal("Appliance"),
+				"compute_offering": cty.StringVal(fakeComputeOffering),
+				"public_key":       cty.StringVal("ssh-rsa AAAA"),
+			},
+			err: "doesn't support SSH keys",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			attributes := map[string]cty.Value{
+				"environment_id": cty.StringVal(fake.environmentID),
+				"name":           cty.StringVal("foobar"),
+				"template":       cty.StringVal(fakeTemplate),
+				"network_id":     cty.StringVal("network"),
+			}
+			for key, value := range test.attributes {
+				attributes[key] = value
+			}
+			err := planUnitResource(resourceHciInstance(), meta, attributes)
+			if test.err == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestUnitInstanceSizingValidationOnUpdate(t *testing.T) {
+	fake := newFakeAPI(t)
+	meta := fake.meta(t, fakeAPIKey)
+
+	// the retired template and compute offering aren't in the catalogue anymore, so checking them fails
+	for _, test := range []struct {
+		name       string
+		state      map[string]string
+		attributes map[string]cty.Value
+		err        bool
+	}{
+		{
+			name:       "root volume size change with a retired compute offering",
+			state:      map[string]string{"template": strings.ToLower(fakeTemplate), "compute_offering": "retired"},
+			attributes: map[string]cty.Value{"template": cty.StringVal(fakeTemplate), "compute_offering": cty.StringVal("retired"), "root_volume_size_in_gb": cty.NumberIntVal(50)},
+		},
+		{
+			name:       "memory change with a retired template",
+			state:      map[string]string{"template": "retired", "compute_offering": strings.ToLower(fakeCustomComputeOffering), "cpu_count": "1", "memory_in_mb": "1024"},
+			attributes: map[string]cty.Value{"template": cty.StringVal("retired"), "compute_offering": cty.StringVal(fakeCustomComputeOffering), "cpu_count": cty.NumberIntVal(1), "memory_in_mb": cty.NumberIntVal(2048)},
+		},
+		{
+			name:       "root volume size change with a retired template",
+			state:      map[string]string{"template": "retired", "compute_offering": strings.ToLower(fakeComputeOffering)},
+			attributes: map[string]cty.Value{"template": cty.StringVal("retired"), "compute_offering": cty.StringVal(fakeComputeOffering), "root_volume_size_in_gb": cty.NumberIntVal(50)},
+			err:        true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			state := map[string]string{"id": "instance", "environment_id": fake.environmentID, "name": "foobar", "network_id": "network"}
+			attributes := map[string]cty.Value{
+				"environment_id": cty.StringVal(fake.environmentID),
+				"name":           cty.StringVal("foobar"),
+				"network_id":     cty.StringVal("network"),
+			}
+			for key, value := range test.state {
+				state[key] = value
+			}
+			for key, value := range test.attributes {
+				attributes[key] = value
+			}
+			_, err := planUnitUpdate(resourceHciInstance(), meta, "instance", state, attributes)
+			if !test.err && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			if test.err && err == nil {
+				t.Error("expected checking the retired template to fail")
+			}
+		})
+	}
+}
+
+func TestInstanceSSHKeyConflictsWithPublicKey(t *testing.T) {
+	diags := resourceHciInstance().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name":             "foobar",
+		"template":         fakeTemplate,
+		"compute_offering": fakeComputeOffering,
+		"network_id":       "network",
+		"ssh_key_name":     "foobar",
+		"public_key":       "ssh-rsa AAAA",
+	}))
+	if !diags.HasError() {
+		t.Error("expected ssh_key_name to conflict with public_key")
+	}
+}
+
+func TestChangeAffinityGroupsRestartsInstanceOnFailure(t *testing.T) {
+	fake := newFakeAPI(t)
+	fake.fail("instances", "changeAffinityGroups", "INVALID_AFFINITY_GROUP")
+	id := fake.add("instances", fakeEntity{"name": "foobar", "state": "Running"})
+	meta := fake.meta(t, fakeAPIKey)
+	resources, err := getResourcesForEnvironmentID(context.Background(), meta, fake.environmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourceHciInstance().Schema, map[string]interface{}{
+		"affinity_group_ids": []interface{}{"group"},
+	})
+	d.SetId(id)
+	if err := changeAffinityGroups(resources, d); err == nil {
+		t.Fatal("expected changing the affinity groups to fail")
+	}
+	if state := fake.get("instances", id)["state"]; state != "Running" {
+		t.Errorf("expected the instance to be running again, got %v", state)
+	}
+}
+
+func TestUnitInstanceNetworkMoveKeptWhenUpdateFails(t *testing.T) {
+	fake := newFakeAPI(t)
+	fake.fail("instances", "changeAffinityGroups", "INVALID_AFFINITY_GROUP")
+	network := fake.add("networks", fakeEntity{"name": "network"})
+	other := fake.add("networks", fakeEntity{"name": "other"})
+	id := fake.add("instances", fakeEntity{"name": "foobar", "state": "Running", "networkId": network, "ipAddress": "10.0.0.5", "ipAddressId": "ip"})
+	meta := fake.meta(t, fakeAPIKey)
+	state := map[string]string{
+		"id":               id,
+		"environment_id":   fake.environmentID,
+		"name":             "foobar",
+		"template":         strings.ToLower(fakeTemplate),
+		"compute_offering": strings.ToLower(fakeComputeOffering),
+		"network_id":       network,
+		"private_ip":       "10.0.0.5",
+		"private_ip_id":    "ip",
+		"power_state":      powerStateRunning,
+	}
+
+	r := resourceHciInstance()
+	diff, err := planUnitUpdate(r, meta, id, state, map[string]cty.Value{
+		"environment_id":     cty.StringVal(fake.environmentID),
+		"name":               cty.StringVal("foobar"),
+		"template":           cty.StringVal(fakeTemplate),
+		"compute_offering":   cty.StringVal(fakeComputeOffering),
+		"network_id":         cty.StringVal(other),
+		"affinity_group_ids": cty.SetVal([]cty.Value{cty.StringVal("group")}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newState, diags := r.Apply(context.Background(), &terraform.InstanceState{ID: id, Attributes: state}, diff, meta)
+	if !diags.HasError() {
+		t.Fatal("expected the update to fail")
+	}
+	instance := fake.get("instances", id)
+	for key, expected := range map[string]interface{}{
+		"network_id":           other,
+		"private_ip":           instance["ipAddress"],
+		"private_ip_id":        instance["ipAddressId"],
+		"affinity_group_ids.#": nil,
+	} {
+		if value, ok := newState.Attributes[key]; expected != nil && value != expected || expected == nil && ok && value != "0" {
+			t.Errorf("expected %s to be %v in the state, got %q", key, expected, value)
+		}
+	}
+}
+
+func TestUnitInstancePrivateIPValidation(t *testing.T) {
+	fake := newFakeAPI(t)
+	meta := fake.meta(t, fakeAPIKey)
+	state := map[string]string{
+		"id":               "instance",
+		"environment_id":   fake.environmentID,
+		"name":             "foobar",
+		"template":         fakeTemplate,
+		"compute_offering": strings.ToLower(fakeComputeOffering),
+		"network_id":       "network",
+		"private_ip_id":    "ip",
+		"private_ip":       "10.0.0.5",
+	}
+
+	for _, test := range []struct {
+		name       string
+		attributes map[string]cty.Value
+		err        string
+	}{
+		{
+			name:       "network change",
+			attributes: map[string]cty.Value{"network_id": cty.StringVal("other")},
+		},
+		{
+			name:       "unchanged private IP",
+			attributes: map[string]cty.Value{"network_id": cty.StringVal("network"), "private_ip": cty.StringVal("10.0.0.5")},
+		},
+		{
+			name:       "private IP change",
+			attributes: map[string]cty.Value{"network_id": cty.StringVal("network"), "private_ip": cty.StringVal("10.0.0.6")},
+			err:        "Cannot update the private IP",
+		},
+		{
+			name:       "private IP with a network change",
+			attributes: map[string]cty.Value{"network_id": cty.StringVal("other"), "private_ip": cty.StringVal("10.0.0.5")},
+			err:        "private_ip can't be set when changing network_id",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			attributes := map[string]cty.Value{
+				"environment_id":   cty.StringVal(fake.environmentID),
+				"name":             cty.StringVal("foobar"),
+				"template":         cty.StringVal(fakeTemplate),
+				"compute_offering": cty.StringVal(fakeComputeOffering),
+			}
+			for key, value := range test.attributes {
+				attributes[key] = value
+			}
+			_, err := planUnitUpdate(resourceHciInstance(), meta, "instance", state, attributes)
+			if test.err == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestRecoverDestroyedInstanceMatchesTemplateComputeOfferingNetworkAndZone(t *testing.T) {
+	fake := newFakeAPI(t)
+	templateID := fake.idOf("templates", fakeTemplate)
+	computeOfferingID := fake.idOf("computeofferings", fakeComputeOffering)
+	destroyed := func(field, value string) string {
+		instance := fakeEntity{"name": "foobar", "state": "Destroyed", "templateId": templateID,
+			"computeOfferingId": computeOfferingID, "networkId": "network", "zoneId": "zone"}
+		if field != "" {
+			instance[field] = value
+		}
+		return fake.add("instances", instance)
+	}
+	others := []string{
+		destroyed("templateId", "other"),
+		destroyed("computeOfferingId", "other"),
+		destroyed("networkId", "other"),
+		destroyed("zoneId", "other"),
+	}
+	meta := fake.meta(t, fakeAPIKey)
+	resources, err := getResourcesForEnvironmentID(context.Background(), meta, fake.environmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceToCreate := hci.Instance{Name: "foobar", TemplateId: templateID, ComputeOfferingId: computeOfferingID, NetworkId: "network", ZoneId: "zone"}
+	recovered, err := recoverDestroyedInstance(resources, instanceToCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered != nil {
+		t.Fatalf("expected no instance to be recovered, got %+v", recovered)
+	}
+
+	matching := destroyed("", "")
+	recovered, err = recoverDestroyedInstance(resources, instanceToCreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered == nil || recovered.Id != matching || fake.get("instances", matching)["state"] != "Stopped" {
+		t.Errorf("expected instance %s to be recovered, got %+v", matching, recovered)
+	}
+	for _, id := range others {
+		if fake.get("instances", id)["state"] != "Destroyed" {
+			t.Errorf("expected instance %s not to be recovered", id)
+		}
+	}
+}
+
+func TestRecoverDestroyedInstanceFailsWhenSeveralMatch(t *testing.T) {
+	fake := newFakeAPI(t)
+	templateID := fake.idOf("templates", fakeTemplate)
+	computeOfferingID := fake.idOf("computeofferings", fakeComputeOffering)
+	ids := []string{}
+	for i := 0; i < 2; i++ {
+		ids = append(ids, fake.add("instances", fakeEntity{"name": "foobar", "state": "Destroyed", "templateId": templateID,
+			"computeOfferingId": computeOfferingID, "networkId": "network", "zoneId": "zone"}))
+	}
+	meta := fake.meta(t, fakeAPIKey)
+	resources, err := getResourcesForEnvironmentID(context.Background(), meta, fake.environmentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without a zone, the zone of the destroyed instances doesn't matter
+	instanceToCreate := hci.Instance{Name: "foobar", TemplateId: templateID, ComputeOfferingId: computeOfferingID, NetworkId: "network"}
+	recovered, err := recoverDestroyedInstance(resources, instanceToCreate)
+	if err == nil || !strings.Contains(err.Error(), "Several destroyed instances") {
+		t.Errorf("expected an error about several destroyed instances, got %v", err)
+	}
+	if recovered != nil {
+		t.Errorf("expected no instance to be recovered, got %+v", recovered)
+	}
+	for _, id := range ids {
+		if fake.get("instances", id)["state"] != "Destroyed" {
+			t.Errorf("expected instance %s not to be recovered", id)
+		}
+	}
+}
+
+func TestUnitInstanceAffinityGroupsPlan(t *testing.T) {
+	fake := newFakeAPI(t)
+	meta := fake.meta(t, fakeAPIKey)
+	hash := schema.HashSchema(&schema.Schema{Type: schema.TypeString})
+	state := map[string]string{
+		"id":                   "instance",
+		"environment_id":       fake.environmentID,
+		"name":                 "foobar",
+		"template":             fakeTemplate,
+		"compute_offering":     strings.ToLower(fakeComputeOffering),
+		"network_id":           "network",
+		"affinity_group_ids.#": "1",
+		fmt.Sprintf("affinity_group_ids.%d", hash("group")): "group",
+	}
+
+	for _, test := range []struct {
+		name     string
+		groups   cty.Value
+		expected []string
+	}{
+		{name: "not set", groups: cty.NullVal(cty.Set(cty.String))},
+		{name: "unchanged", groups: cty.SetVal([]cty.Value{cty.StringVal("group")})},
+		{name: "empty", groups: cty.SetValEmpty(cty.String), expected: []string{}},
+		{name: "changed", groups: cty.SetVal([]cty.Value{cty.StringVal("other")}), expected: []string{"other"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := planUnitUpdate(resourceHciInstance(), meta, "instance", state, map[string]cty.Value{
+				"environment_id":     cty.StringVal(fake.environmentID),
+				"name":               cty.StringVal("foobar"),
+				"template":           cty.StringVal(fakeTemplate),
+				"compute_offering":   cty.StringVal(fakeComputeOffering),
+				"network_id":         cty.StringVal("network"),
+				"affinity_group_ids": test.groups,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expected == nil {
+				for key, attribute := range diff.Attributes {
+					if strings.HasPrefix(key, "affinity_group_ids.") && (attribute.Old != attribute.New || attribute.NewComputed || attribute.NewRemoved) {
+						t.Errorf("expected no change of the affinity groups, got %s: %+v", key, attribute)
+					}
+				}
+				return
+			}
+			count := diff.Attributes["affinity_group_ids.#"]
+			if count == nil || count.New != fmt.Sprint(len(test.expected)) {
+				t.Fatalf("expected %d affinity groups to be planned, got %+v", len(test.expected), count)
+			}
+			for _, id := range test.expected {
+				if group := diff.Attributes[fmt.Sprintf("affinity_group_ids.%d", hash(id))]; group == nil || group.New != id {
+					t.Errorf("expected affinity group %s to be planned, got %+v", id, diff.Attributes)
+				}
+			}
+		})
+	}
+}
@@ -1,29 +1,39 @@
 package hci
 
 import (
-	"errors"
-	"fmt"
+	"context"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciLoadBalancerRule() *schema.Resource {
 	return &schema.Resource{
-		Create: createLbr,
-		Read:   readLbr,
-		Delete: deleteLbr,
-		Update: updateLbr,
+		CreateContext: createLbr,
+		ReadContext:   readLbr,
+		DeleteContext: deleteLbr,
+		UpdateContext: updateLbr,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where load balancer rule should be created",
 			},
@@ -50,27 +60,31 @@ func resourceHciLoadBalancerRule() *schema.Resource {
 				Description: "The network ID to bind to",
 			},
 			"protocol": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The protocol that this rule should use (eg. TCP, UDP)",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateOneOf(portProtocols...),
+				Description:      "The protocol that this rule should use (eg. TCP, UDP)",
 			},
 			"algorithm": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The algorithm used to load balance",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validateOneOf(loadBalancingAlgorithms...),
+				Description:      "The algorithm used to load balance",
 			},
 			"public_port": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The port on the public IP",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The port on the public IP",
 			},
 			"private_port": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The port to which the traffic will be load balanced internally",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The port to which the traffic will be load balanced internally",
 			},
 			"instance_ids": {
 				Type:        schema.TypeSet,
@@ -92,11 +106,11 @@ func resourceHciLoadBalancerRule() *schema.Resource {
 	}
 }
 
-func createLbr(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func createLbr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	lbr := hci.LoadBalancerRule{
@@ -129,89 +143,89 @@ func createLbr(d *schema.ResourceData, meta interface{}) error {
 
 	newLbr, err := hciResources.LoadBalancerRules.Create(lbr)
 	if err != nil {
-		return err
+		return diagFromError(err)
 	}
 
 	d.SetId(newLbr.Id)
-	return readLbr(d, meta)
+	return readLbr(ctx, d, meta)
 }
 
-func readLbr(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func readLbr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	lbr, err := hciResources.LoadBalancerRules.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("Load balancer rule", false, err, d)
+		return diagFromError(handleNotFoundError("Load balancer rule", false, err, d))
 	}
 
 	if err := d.Set("name", lbr.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("public_ip_id", lbr.PublicIpId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("network_id", lbr.NetworkId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("instance_ids", lbr.InstanceIds); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("algorithm", lbr.Algorithm); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("protocol", lbr.Protocol); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("public_port", lbr.PublicPort); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("private_port", lbr.PrivatePort); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("public_ip", lbr.PublicIp); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("stickiness_method", lbr.StickinessMethod); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("stickiness_params", lbr.StickinessPolicyParameters); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func deleteLbr(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func deleteLbr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if err := hciResources.LoadBalancerRules.Delete(d.Id()); err != nil {
-		return handleNotFoundError("Load balancer rule", true, err, d)
+		return diagFromError(handleNotFoundError("Load balancer rule", true, err, d))
 	}
 	return nil
 }
 
-func updateLbr(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func updateLbr(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	d.Partial(true)
@@ -225,16 +239,16 @@ func updateLbr(d *schema.ResourceData, meta interface{}) error {
 			}
 			err := hciResources.LoadBalancerRules.SetLoadBalancerRuleStickinessPolicy(d.Id(), stickinessMethod.(string), stickinessPolicyParameters)
 			if err != nil {
-				return err
+				return diagFromError(err)
 			}
 		} else {
 
 			if _, ok := d.GetOk("stickiness_params"); ok {
-				return errors.New("Stickiness params should be removed if the stickiness method is removed")
+				return diag.Errorf("Stickiness params should be removed if the stickiness method is removed")
 			}
 			err := hciResources.LoadBalancerRules.RemoveLoadBalancerRuleStickinessPolicy(d.Id())
 			if err != nil {
-				return err
+				return diagFromError(err)
 			}
 		}
 	}
@@ -244,7 +258,7 @@ func updateLbr(d *schema.ResourceData, meta interface{}) error {
 		newAlgorithm := d.Get("algorithm").(string)
 		_, err := hciResources.LoadBalancerRules.Update(hci.LoadBalancerRule{Id: d.Id(), Name: newName, Algorithm: newAlgorithm})
 		if err != nil {
-			return err
+			return diagFromError(err)
 		}
 	}
 
@@ -256,11 +270,11 @@ func updateLbr(d *schema.ResourceData, meta interface{}) error {
 
 		instanceErr := hciResources.LoadBalancerRules.SetLoadBalancerRuleInstances(d.Id(), instanceIds)
 		if instanceErr != nil {
-			return instanceErr
+			return diagFromError(instanceErr)
 		}
 	}
 	d.Partial(false)
-	return readLbr(d, meta)
+	return readLbr(ctx, d, meta)
 }
 
 func getStickinessPolicyParameterMap(policyMap map[string]interface{}) map[string]string {
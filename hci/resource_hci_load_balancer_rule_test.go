@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccLoadBalancerRuleCreate(t *testing.T) {
 	t.Parallel()
 
-	instanceName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	instanceName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -73,8 +72,8 @@ func testAccCheckLoadBalancerRuleCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -94,7 +93,7 @@ func testAccCheckLoadBalancerRuleCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckLoadBalancerRuleCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_load_balancer_rule" {
@@ -102,7 +101,7 @@ func testAccCheckLoadBalancerRuleCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -116,3 +115,62 @@ func testAccCheckLoadBalancerRuleCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitLoadBalancerRuleLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_load_balancer_rule", "loadbalancerrules"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(testUnitLoadBalancerRule("foobar", "roundrobin", `
+	instance_ids = [hci_instance.fake.id]`)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_load_balancer_rule.foobar", "instance_ids.#", "1"),
+					resource.TestCheckResourceAttrPair("hci_load_balancer_rule.foobar", "public_ip", "hci_public_ip.foobar", "ip_address"),
+					fake.checkEntity("hci_load_balancer_rule.foobar", "loadbalancerrules", "algorithm", "roundrobin"),
+				),
+			},
+			{
+				Config: fake.config(testUnitLoadBalancerRule("renamed", "leastconn", `
+	stickiness_method = "LbCookie"
+	stickiness_params = {
+		cookieName = "foobar"
+	}`)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_load_balancer_rule.foobar", "instance_ids.#", "0"),
+					fake.checkEntity("hci_load_balancer_rule.foobar", "loadbalancerrules", "name", "renamed"),
+					fake.checkEntity("hci_load_balancer_rule.foobar", "loadbalancerrules", "algorithm", "leastconn"),
+					fake.checkEntity("hci_load_balancer_rule.foobar", "loadbalancerrules", "stickinessMethod", "LbCookie"),
+				),
+			},
+			{
+				ResourceName:      "hci_load_balancer_rule.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testUnitLoadBalancerRule(name, algorithm, extra string) string {
+	return fmt.Sprintf(fakeInstanceConfig+`
+resource "hci_public_ip" "foobar" {
+	vpc_id = hci_vpc.fake.id
+}
+
+resource "hci_load_balancer_rule" "foobar" {
+	name         = "%s"
+	public_ip_id = hci_public_ip.foobar.id
+	network_id   = hci_network.fake.id
+	protocol     = "tcp"
+	public_port  = "80"
+	private_port = "80"
+	algorithm    = "%s"
+	%s
+}`, name, algorithm, extra)
+}
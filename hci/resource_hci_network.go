@@ -1,31 +1,43 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/api"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciNetwork() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciNetworkCreate,
-		Read:   resourceHciNetworkRead,
-		Update: resourceHciNetworkUpdate,
-		Delete: resourceHciNetworkDelete,
+		CreateContext: resourceHciNetworkCreate,
+		ReadContext:   resourceHciNetworkRead,
+		UpdateContext: resourceHciNetworkUpdate,
+		DeleteContext: resourceHciNetworkDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where network should be created",
 			},
@@ -70,20 +82,20 @@ func resourceHciNetwork() *schema.Resource {
 	}
 }
 
-func resourceHciNetworkCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	networkOfferingID, nerr := retrieveNetworkOfferingID(&hciResources, d.Get("network_offering").(string))
 	if nerr != nil {
-		return nerr
+		return diagFromError(nerr)
 	}
 
 	aclID, nerr := retrieveNetworkACLID(&hciResources, d.Get("network_acl").(string), d.Get("vpc_id").(string))
 	if nerr != nil {
-		return nerr
+		return diagFromError(nerr)
 	}
 
 	networkToCreate := hci.Network{
@@ -99,17 +111,17 @@ func resourceHciNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	newNetwork, err := hciResources.Networks.Create(networkToCreate, options)
 	if err != nil {
-		return fmt.Errorf("Error creating the new network %s: %s", networkToCreate.Name, err)
+		return diagFromErrorf(err, "Error creating the new network %s", networkToCreate.Name)
 	}
 	d.SetId(newNetwork.Id)
-	return resourceHciNetworkRead(d, meta)
+	return resourceHciNetworkRead(ctx, d, meta)
 }
 
-func resourceHciNetworkRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	network, err := hciResources.Networks.Get(d.Id())
 	if err != nil {
@@ -120,47 +132,47 @@ func resourceHciNetworkRead(d *schema.ResourceData, meta interface{}) error {
 				return nil
 			}
 		}
-		return err
+		return diagFromError(err)
 	}
 
 	offering, offErr := hciResources.NetworkOfferings.Get(network.NetworkOfferingId)
 	if offErr != nil {
-		return handleNotFoundError("Network", false, offErr, d)
+		return diagFromError(handleNotFoundError("Network", false, offErr, d))
 	}
 
 	// Update the config
 	if err := d.Set("name", network.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("description", network.Description); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := setValueOrID(d, "network_offering", offering.Name, network.NetworkOfferingId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("vpc_id", network.VpcId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := setValueOrID(d, "network_acl", network.NetworkAclName, network.NetworkAclId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("cidr", network.Cidr); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	d.Partial(true)
 
@@ -169,18 +181,18 @@ func resourceHciNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
 		newDescription := d.Get("description").(string)
 		_, err := hciResources.Networks.Update(d.Id(), hci.Network{Id: d.Id(), Name: newName, Description: newDescription})
 		if err != nil {
-			return err
+			return diagFromError(err)
 		}
 	}
 
 	if d.HasChange("network_acl") {
 		aclID, err := retrieveNetworkACLID(&hciResources, d.Get("network_acl").(string), d.Get("vpc_id").(string))
 		if err != nil {
-			return err
+			return diagFromError(err)
 		}
 		_, aclErr := hciResources.Networks.ChangeAcl(d.Id(), aclID)
 		if aclErr != nil {
-			return aclErr
+			return diagFromError(aclErr)
 		}
 	}
 
@@ -189,14 +201,14 @@ func resourceHciNetworkUpdate(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func resourceHciNetworkDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if _, err := hciResources.Networks.Delete(d.Id()); err != nil {
-		return handleNotFoundError("Network", true, err, d)
+		return diagFromError(handleNotFoundError("Network", true, err, d))
 	}
 
 	return nil
@@ -1,27 +1,37 @@
 package hci
 
 import (
-	"fmt"
+	"context"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciNetworkACL() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciNetworkACLCreate,
-		Read:   resourceHciNetworkACLRead,
-		Delete: resourceHciNetworkACLDelete,
+		CreateContext: resourceHciNetworkACLCreate,
+		ReadContext:   resourceHciNetworkACLRead,
+		DeleteContext: resourceHciNetworkACLDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where the network ACL should be created",
 			},
@@ -47,11 +57,11 @@ func resourceHciNetworkACL() *schema.Resource {
 	}
 }
 
-func resourceHciNetworkACLCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkACLCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	aclToCreate := hci.NetworkAcl{
@@ -61,47 +71,47 @@ func resourceHciNetworkACLCreate(d *schema.ResourceData, meta interface{}) error
 	}
 	newACL, err := hciResources.NetworkAcls.Create(aclToCreate)
 	if err != nil {
-		return fmt.Errorf("Error creating the new network ACL %s: %s", aclToCreate.Name, err)
+		return diagFromErrorf(err, "Error creating the new network ACL %s", aclToCreate.Name)
 	}
 	d.SetId(newACL.Id)
-	return resourceHciNetworkACLRead(d, meta)
+	return resourceHciNetworkACLRead(ctx, d, meta)
 }
 
-func resourceHciNetworkACLRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkACLRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	acl, aErr := hciResources.NetworkAcls.Get(d.Id())
 	if aErr != nil {
-		return handleNotFoundError("Network ACL", false, aErr, d)
+		return diagFromError(handleNotFoundError("Network ACL", false, aErr, d))
 	}
 
 	// Update the config
 	if err := d.Set("name", acl.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("description", acl.Description); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("vpc_id", acl.VpcId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciNetworkACLDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkACLDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if _, err := hciResources.NetworkAcls.Delete(d.Id()); err != nil {
-		return handleNotFoundError("Network ACL", true, err, d)
+		return diagFromError(handleNotFoundError("Network ACL", true, err, d))
 	}
 	return nil
 }
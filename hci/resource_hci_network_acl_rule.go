@@ -1,11 +1,13 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
@@ -14,23 +16,34 @@ const (
 	TCP  = "TCP"
 	UDP  = "UDP"
 	ICMP = "ICMP"
+	ALL  = "ALL"
 )
 
 func resourceHciNetworkACLRule() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciNetworkACLRuleCreate,
-		Update: resourceHciNetworkACLRuleUpdate,
-		Read:   resourceHciNetworkACLRuleRead,
-		Delete: resourceHciNetworkACLRuleDelete,
+		CreateContext: resourceHciNetworkACLRuleCreate,
+		UpdateContext: resourceHciNetworkACLRuleUpdate,
+		ReadContext:   resourceHciNetworkACLRuleRead,
+		DeleteContext: resourceHciNetworkACLRuleDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: resourceHciNetworkACLRuleCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where the network ACL rule should be created",
 			},
@@ -40,54 +53,62 @@ func resourceHciNetworkACLRule() *schema.Resource {
 				Description: "The rule number of network ACL",
 			},
 			"cidr": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The network ACL rule cidr",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validateCIDR,
+				Description:      "The network ACL rule cidr",
 			},
 			"action": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The network ACL rule action (i.e. Allow or Deny)",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validateOneOf("Allow", "Deny"),
+				Description:      "The network ACL rule action (i.e. Allow or Deny)",
 				StateFunc: func(val interface{}) string {
 					return strings.ToLower(val.(string))
 				},
 			},
 			"protocol": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The network ACL rule protocol (i.e. TCP, UDP, ICMP or All)",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateOneOf(TCP, UDP, ICMP, ALL),
+				Description:      "The network ACL rule protocol (i.e. TCP, UDP, ICMP or All)",
 				StateFunc: func(val interface{}) string {
 					return strings.ToLower(val.(string))
 				},
 			},
 			"traffic_type": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The network ACL rule traffc type (i.e. Ingress or Egress)",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validateOneOf("Ingress", "Egress"),
+				Description:      "The network ACL rule traffc type (i.e. Ingress or Egress)",
 				StateFunc: func(val interface{}) string {
 					return strings.ToLower(val.(string))
 				},
 			},
 			"icmp_type": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The ICMP type. Can only be used with ICMP protocol.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validateICMPValue,
+				Description:      "The ICMP type. Can only be used with ICMP protocol.",
 			},
 			"icmp_code": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The ICMP code. Can only be used with ICMP protocol.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validateICMPValue,
+				Description:      "The ICMP code. Can only be used with ICMP protocol.",
 			},
 			"start_port": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The start port. Can only be used with TCP/UDP protocol.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The start port. Can only be used with TCP/UDP protocol.",
 			},
 			"end_port": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The end port. Can only be used with TCP/UDP protocol.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The end port. Can only be used with TCP/UDP protocol.",
 			},
 			"network_acl_id": {
 				Type:        schema.TypeString,
@@ -99,11 +120,11 @@ func resourceHciNetworkACLRule() *schema.Resource {
 	}
 }
 
-func resourceHciNetworkACLRuleCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkACLRuleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	aclRuleToCreate := hci.NetworkAclRule{
 		RuleNumber:   d.Get("rule_number").(string),
@@ -116,25 +137,25 @@ func resourceHciNetworkACLRuleCreate(d *schema.ResourceData, meta interface{}) e
 	fillPortFields(d, &aclRuleToCreate)
 	fillIcmpFields(d, &aclRuleToCreate)
 	if !(strings.EqualFold(TCP, aclRuleToCreate.Protocol) || strings.EqualFold(UDP, aclRuleToCreate.Protocol)) && (aclRuleToCreate.StartPort != "" || aclRuleToCreate.EndPort != "") {
-		return fmt.Errorf("Cannot have ports if not TCP or UDP protocol")
+		return diag.Errorf("Cannot have ports if not TCP or UDP protocol")
 	}
 	if !strings.EqualFold(ICMP, aclRuleToCreate.Protocol) && (aclRuleToCreate.IcmpType != "" || aclRuleToCreate.IcmpCode != "") {
-		return fmt.Errorf("Cannot have icmp fields if not ICMP protocol")
+		return diag.Errorf("Cannot have icmp fields if not ICMP protocol")
 	}
 
 	newACLRule, err := hciResources.NetworkAclRules.Create(aclRuleToCreate)
 	if err != nil {
-		return fmt.Errorf("Error creating the new network ACL rule %s: %s", aclRuleToCreate.RuleNumber, err)
+		return diagFromErrorf(err, "Error creating the new network ACL rule %s", aclRuleToCreate.RuleNumber)
 	}
 	d.SetId(newACLRule.Id)
-	return resourceHciNetworkACLRuleRead(d, meta)
+	return resourceHciNetworkACLRuleRead(ctx, d, meta)
 }
 
-func resourceHciNetworkACLRuleUpdate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkACLRuleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	aclRuleToUpdate := hci.NetworkAclRule{
 		Id:          d.Id(),
@@ -149,69 +170,98 @@ func resourceHciNetworkACLRuleUpdate(d *schema.ResourceData, meta interface{}) e
 
 	_, err := hciResources.NetworkAclRules.Update(d.Id(), aclRuleToUpdate)
 	if err != nil {
-		return err
+		return diagFromError(err)
 	}
 	return nil
 }
 
-func resourceHciNetworkACLRuleRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkACLRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	aclRule, aErr := hciResources.NetworkAclRules.Get(d.Id())
 	if aErr != nil {
-		return handleNotFoundError("Network ACL rule", false, aErr, d)
+		return diagFromError(handleNotFoundError("Network ACL rule", false, aErr, d))
 	}
 
 	if err := d.Set("rule_number", aclRule.RuleNumber); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("action", strings.ToLower(aclRule.Action)); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("protocol", strings.ToLower(aclRule.Protocol)); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("traffic_type", strings.ToLower(aclRule.TrafficType)); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("icmp_type", aclRule.IcmpType); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("icmp_code", aclRule.IcmpCode); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("start_port", aclRule.StartPort); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("end_port", aclRule.EndPort); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("network_acl_id", aclRule.NetworkAclId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciNetworkACLRuleDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciNetworkACLRuleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if _, err := hciResources.NetworkAclRules.Delete(d.Id()); err != nil {
-		return handleNotFoundError("Network ACL rule", true, err, d)
+		return diagFromError(handleNotFoundError("Network ACL rule", true, err, d))
+	}
+	return nil
+}
+
+func resourceHciNetworkACLRuleCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if err := customizeDiffDefaultEnvironment(ctx, diff, meta); err != nil {
+		return err
+	}
+	return validateNetworkACLRuleProtocol(diff)
+}
+
+// validateNetworkACLRuleProtocol checks at plan time that ports are only set for TCP and UDP, ICMP types and codes
+// only for ICMP, and that the start port isn't greater than the end port.
+func validateNetworkACLRuleProtocol(diff *schema.ResourceDiff) error {
+	if !configValuesKnown(diff, "protocol", "start_port", "end_port", "icmp_type", "icmp_code") {
+		return nil
+	}
+	protocol := diff.Get("protocol").(string)
+	hasPorts := diff.Get("start_port").(string) != "" || diff.Get("end_port").(string) != ""
+	if hasPorts && !(strings.EqualFold(TCP, protocol) || strings.EqualFold(UDP, protocol)) {
+		return fmt.Errorf("Cannot have ports if not TCP or UDP protocol")
+	}
+	if !strings.EqualFold(ICMP, protocol) && (diff.Get("icmp_type").(string) != "" || diff.Get("icmp_code").(string) != "") {
+		return fmt.Errorf("Cannot have icmp fields if not ICMP protocol")
+	}
+	if diff.Get("start_port").(string) != "" && diff.Get("end_port").(string) != "" {
+		if _, err := portRangeLength(diff, "start_port", "end_port"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
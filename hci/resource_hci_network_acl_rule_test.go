@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccNetworkACLRuleCreate(t *testing.T) {
 	t.Parallel()
 
-	networkACLRuleName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	networkACLRuleName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -66,8 +65,8 @@ func testAccCheckNetworkACLRuleCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -87,7 +86,7 @@ func testAccCheckNetworkACLRuleCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckNetworkACLRuleCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_network_acl_rule" {
@@ -95,7 +94,7 @@ func testAccCheckNetworkACLRuleCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -109,3 +108,58 @@ func testAccCheckNetworkACLRuleCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitNetworkACLRuleLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_network_acl_rule", "networkaclrules"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(testUnitNetworkACLRule("10", "allow", "80")),
+				Check: resource.ComposeTestCheckFunc(
+					fake.checkEntity("hci_network_acl_rule.foobar", "networkaclrules", "ruleNumber", "10"),
+					fake.checkEntity("hci_network_acl_rule.foobar", "networkaclrules", "action", "allow"),
+				),
+			},
+			{
+				Config: fake.config(testUnitNetworkACLRule("20", "deny", "81")),
+				Check: resource.ComposeTestCheckFunc(
+					fake.checkEntity("hci_network_acl_rule.foobar", "networkaclrules", "ruleNumber", "20"),
+					fake.checkEntity("hci_network_acl_rule.foobar", "networkaclrules", "action", "deny"),
+					fake.checkEntity("hci_network_acl_rule.foobar", "networkaclrules", "endPort", "81"),
+				),
+			},
+			{
+				ResourceName:            "hci_network_acl_rule.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"cidr"},
+			},
+		},
+	})
+}
+
+func testUnitNetworkACLRule(ruleNumber, action, endPort string) string {
+	return fmt.Sprintf(fakeNetworkConfig+`
+resource "hci_network_acl" "foobar" {
+	name        = "foobar"
+	description = "This is a foobar acl"
+	vpc_id      = hci_vpc.fake.id
+}
+
+resource "hci_network_acl_rule" "foobar" {
+	network_acl_id = hci_network_acl.foobar.id
+	rule_number    = "%s"
+	cidr           = "10.0.0.0/8"
+	action         = "%s"
+	protocol       = "tcp"
+	start_port     = "80"
+	end_port       = "%s"
+	traffic_type   = "ingress"
+}`, ruleNumber, action, endPort)
+}
@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccNetworkACLCreate(t *testing.T) {
 	t.Parallel()
 
-	networkACLName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	networkACLName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -55,8 +54,8 @@ func testAccCheckNetworkACLCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -76,7 +75,7 @@ func testAccCheckNetworkACLCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckNetworkACLCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_network_acl" {
@@ -84,7 +83,7 @@ func testAccCheckNetworkACLCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -98,3 +97,34 @@ func testAccCheckNetworkACLCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitNetworkACLLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_network_acl", "networkacls"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(fakeNetworkConfig + `
+resource "hci_network_acl" "foobar" {
+	name        = "foobar"
+	description = "This is a foobar acl"
+	vpc_id      = hci_vpc.fake.id
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("hci_network_acl.foobar", "vpc_id", "hci_vpc.fake", "id"),
+					fake.checkEntity("hci_network_acl.foobar", "networkacls", "name", "foobar"),
+				),
+			},
+			{
+				ResourceName:      "hci_network_acl.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccNetworkCreate(t *testing.T) {
 	t.Parallel()
 
-	networkName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	networkName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -57,8 +56,8 @@ func testAccCheckNetworkCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -78,7 +77,7 @@ func testAccCheckNetworkCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckNetworkCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_network" {
@@ -86,7 +85,7 @@ func testAccCheckNetworkCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -100,3 +99,55 @@ func testAccCheckNetworkCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitNetworkLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_network", "networks"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(testUnitNetwork("foobar", "default_allow")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("hci_network.foobar", "cidr"),
+					fake.checkEntity("hci_network.foobar", "networks", "networkAclName", "default_allow"),
+				),
+			},
+			{
+				Config: fake.config(testUnitNetwork("renamed", "foobar")),
+				Check: resource.ComposeTestCheckFunc(
+					fake.checkEntity("hci_network.foobar", "networks", "name", "renamed"),
+					fake.checkEntity("hci_network.foobar", "networks", "networkAclName", "foobar"),
+				),
+			},
+			{
+				ResourceName:      "hci_network.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testUnitNetwork(name, networkACL string) string {
+	return fmt.Sprintf(fakeNetworkConfig+`
+resource "hci_network_acl" "foobar" {
+	name        = "foobar"
+	description = "This is a foobar acl"
+	vpc_id      = hci_vpc.fake.id
+}
+
+resource "hci_network" "foobar" {
+	name             = "%s"
+	description      = "This is a %s network"
+	vpc_id           = hci_vpc.fake.id
+	network_offering = "Standard Network"
+	network_acl      = "%s"
+
+	depends_on = [hci_network_acl.foobar]
+}`, name, name, networkACL)
+}
@@ -1,27 +1,38 @@
 package hci
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciPortForwardingRule() *schema.Resource {
 	return &schema.Resource{
-		Create: createPortForwardingRule,
-		Read:   readPortForwardingRule,
-		Delete: deletePortForwardingRule,
+		CreateContext: createPortForwardingRule,
+		ReadContext:   readPortForwardingRule,
+		DeleteContext: deletePortForwardingRule,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: resourceHciPortForwardingRuleCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where port forwarding rule should be created",
 			},
@@ -38,36 +49,41 @@ func resourceHciPortForwardingRule() *schema.Resource {
 				Description: "The ID of the private IP to bind to",
 			},
 			"protocol": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The protocol that this rule should use (eg. TCP, UDP)",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateOneOf(portProtocols...),
+				Description:      "The protocol that this rule should use (eg. TCP, UDP)",
 			},
 			"private_port_start": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The start of the private port range for this rule",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The start of the private port range for this rule",
 			},
 			"private_port_end": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				ForceNew:    true,
-				Computed:    true,
-				Description: "The end of the private port range for this rule",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Computed:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The end of the private port range for this rule",
 			},
 			"public_port_start": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The start of the public port range for this rule",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The start of the public port range for this rule",
 			},
 			"public_port_end": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				ForceNew:    true,
-				Computed:    true,
-				Description: "The end of the public port range for this rule",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Computed:         true,
+				ValidateDiagFunc: validatePort,
+				Description:      "The end of the public port range for this rule",
 			},
 			"public_ip": {
 				Type:     schema.TypeString,
@@ -85,11 +101,38 @@ func resourceHciPortForwardingRule() *schema.Resource {
 	}
 }
 
-func createPortForwardingRule(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciPortForwardingRuleCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if err := customizeDiffDefaultEnvironment(ctx, diff, meta); err != nil {
+		return err
+	}
+	return validatePortForwardingRulePorts(diff)
+}
+
+// validatePortForwardingRulePorts checks at plan time that the public and private port ranges are valid and
+// have the same number of ports.
+func validatePortForwardingRulePorts(diff *schema.ResourceDiff) error {
+	if !configValuesKnown(diff, "public_port_start", "public_port_end", "private_port_start", "private_port_end") {
+		return nil
+	}
+	publicPorts, err := portRangeLength(diff, "public_port_start", "public_port_end")
+	if err != nil {
+		return err
+	}
+	privatePorts, err := portRangeLength(diff, "private_port_start", "private_port_end")
+	if err != nil {
+		return err
+	}
+	if publicPorts != privatePorts {
+		return fmt.Errorf("The public port range has %d ports but the private port range has %d", publicPorts, privatePorts)
+	}
+	return nil
+}
+
+func createPortForwardingRule(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	pfr := hci.PortForwardingRule{
 		PublicIpId:       d.Get("public_ip_id").(string),
@@ -109,75 +152,75 @@ func createPortForwardingRule(d *schema.ResourceData, meta interface{}) error {
 
 	newPfr, err := hciResources.PortForwardingRules.Create(pfr)
 	if err != nil {
-		return err
+		return diagFromError(err)
 	}
 
 	d.SetId(newPfr.Id)
-	return readPortForwardingRule(d, meta)
+	return readPortForwardingRule(ctx, d, meta)
 }
 
-func readPortForwardingRule(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func readPortForwardingRule(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	pfr, err := hciResources.PortForwardingRules.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("Port forwarding rule", false, err, d)
+		return diagFromError(handleNotFoundError("Port forwarding rule", false, err, d))
 	}
 
 	if err := d.Set("public_ip_id", pfr.PublicIpId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("private_ip_id", pfr.PrivateIpId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("instance_id", pfr.InstanceId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("protocol", pfr.Protocol); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("public_port_start", pfr.PublicPortStart); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("public_port_end", pfr.PublicPortEnd); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("private_port_start", pfr.PrivatePortStart); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("private_port_end", pfr.PrivatePortEnd); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("private_ip", pfr.PrivateIp); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("public_ip", pfr.PublicIp); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func deletePortForwardingRule(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func deletePortForwardingRule(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if _, err := hciResources.PortForwardingRules.Delete(d.Id()); err != nil {
-		return handleNotFoundError("Port forwarding rule", true, err, d)
+		return diagFromError(handleNotFoundError("Port forwarding rule", true, err, d))
 	}
 	return nil
 }
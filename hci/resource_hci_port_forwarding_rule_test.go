@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccPortForwardingRuleCreate(t *testing.T) {
 	t.Parallel()
 
-	instanceName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	instanceName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -70,8 +69,8 @@ func testAccCheckPortForwardingRuleCreateExists(n string) resource.TestCheckFunc
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -91,7 +90,7 @@ func testAccCheckPortForwardingRuleCreateExists(n string) resource.TestCheckFunc
 }
 
 func testAccCheckPortForwardingRuleCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_port_forwarding_rule" {
@@ -99,7 +98,7 @@ func testAccCheckPortForwardingRuleCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -113,3 +112,42 @@ func testAccCheckPortForwardingRuleCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitPortForwardingRuleLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_port_forwarding_rule", "portforwardingrules"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(fakeInstanceConfig + `
+resource "hci_public_ip" "foobar" {
+	vpc_id = hci_vpc.fake.id
+}
+
+resource "hci_port_forwarding_rule" "foobar" {
+	public_ip_id       = hci_public_ip.foobar.id
+	private_ip_id      = hci_instance.fake.private_ip_id
+	protocol           = "TCP"
+	public_port_start  = "80"
+	private_port_start = "8080"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_port_forwarding_rule.foobar", "public_port_end", "80"),
+					resource.TestCheckResourceAttr("hci_port_forwarding_rule.foobar", "private_port_end", "8080"),
+					resource.TestCheckResourceAttrPair("hci_port_forwarding_rule.foobar", "public_ip", "hci_public_ip.foobar", "ip_address"),
+					resource.TestCheckResourceAttrPair("hci_port_forwarding_rule.foobar", "instance_id", "hci_instance.fake", "id"),
+				),
+			},
+			{
+				ResourceName:      "hci_port_forwarding_rule.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -1,27 +1,37 @@
 package hci
 
 import (
-	"fmt"
+	"context"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciPublicIP() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciPublicIPCreate,
-		Read:   resourceHciPublicIPRead,
-		Delete: resourceHciPublicIPDelete,
+		CreateContext: resourceHciPublicIPCreate,
+		ReadContext:   resourceHciPublicIPRead,
+		DeleteContext: resourceHciPublicIPDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where the public IP should be created",
 			},
@@ -39,11 +49,11 @@ func resourceHciPublicIP() *schema.Resource {
 	}
 }
 
-func resourceHciPublicIPCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciPublicIPCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	vpcID := d.Get("vpc_id").(string)
 
@@ -52,45 +62,45 @@ func resourceHciPublicIPCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	newPublicIP, err := hciResources.PublicIps.Acquire(publicIPToCreate)
 	if err != nil {
-		return fmt.Errorf("Error acquiring the new public IP %s", err)
+		return diagFromErrorf(err, "Error acquiring the new public IP")
 	}
 	d.SetId(newPublicIP.Id)
-	return resourceHciPublicIPRead(d, meta)
+	return resourceHciPublicIPRead(ctx, d, meta)
 }
 
-func resourceHciPublicIPRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciPublicIPRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	publicIP, err := hciResources.PublicIps.Get(d.Id())
 
 	if err != nil {
-		return handleNotFoundError("Public IP", false, err, d)
+		return diagFromError(handleNotFoundError("Public IP", false, err, d))
 	}
 
 	if err := d.Set("vpc_id", publicIP.VpcId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("ip_address", publicIP.IpAddress); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciPublicIPDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciPublicIPDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	if _, err := hciResources.PublicIps.Release(d.Id()); err != nil {
-		return handleNotFoundError("Public IP", true, err, d)
+		return diagFromError(handleNotFoundError("Public IP", true, err, d))
 	}
 
 	return nil
@@ -1,12 +1,12 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccPublicIPCreate(t *testing.T) {
@@ -50,8 +50,8 @@ func testAccCheckPublicIPCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -71,7 +71,7 @@ func testAccCheckPublicIPCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckPublicIPCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_public_ip" {
@@ -79,7 +79,7 @@ func testAccCheckPublicIPCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -93,3 +93,32 @@ func testAccCheckPublicIPCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitPublicIPLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_public_ip", "publicipaddresses"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(fakeNetworkConfig + `
+resource "hci_public_ip" "foobar" {
+	vpc_id = hci_vpc.fake.id
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("hci_public_ip.foobar", "ip_address"),
+					resource.TestCheckResourceAttrPair("hci_public_ip.foobar", "vpc_id", "hci_vpc.fake", "id"),
+				),
+			},
+			{
+				ResourceName:      "hci_public_ip.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
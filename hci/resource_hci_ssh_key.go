@@ -1,27 +1,37 @@
 package hci
 
 import (
-	"fmt"
+	"context"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciSSHKey() *schema.Resource {
 	return &schema.Resource{
-		Create: createSSHKey,
-		Read:   readSSHKey,
-		Delete: deleteSSHKey,
+		CreateContext: createSSHKey,
+		ReadContext:   readSSHKey,
+		DeleteContext: deleteSSHKey,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where the SSH key should be created",
 			},
@@ -40,11 +50,11 @@ func resourceHciSSHKey() *schema.Resource {
 	}
 }
 
-func createSSHKey(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func createSSHKey(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	name := d.Get("name").(string)
 	publicKey := d.Get("public_key").(string)
@@ -55,41 +65,41 @@ func createSSHKey(d *schema.ResourceData, meta interface{}) error {
 	}
 	newSk, err := hciResources.SSHKeys.Create(sk)
 	if err != nil {
-		return fmt.Errorf("Error creating new SSH key %s", err)
+		return diagFromErrorf(err, "Error creating new SSH key")
 	}
 	d.SetId(newSk.ID)
-	return readSSHKey(d, meta)
+	return readSSHKey(ctx, d, meta)
 }
 
-func readSSHKey(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func readSSHKey(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	sk, err := hciResources.SSHKeys.Get(d.Id())
 
 	if err != nil {
-		return handleNotFoundError("SSH key", false, err, d)
+		return diagFromError(handleNotFoundError("SSH key", false, err, d))
 	}
 
 	if err := d.Set("name", sk.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func deleteSSHKey(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func deleteSSHKey(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	if _, err := hciResources.SSHKeys.Delete(d.Id()); err != nil {
-		return handleNotFoundError("SSH key", true, err, d)
+		return diagFromError(handleNotFoundError("SSH key", true, err, d))
 	}
 
 	return nil
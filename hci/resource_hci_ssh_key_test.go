@@ -1,6 +1,7 @@
 package hci
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"fmt"
@@ -8,17 +9,15 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 	"golang.org/x/crypto/ssh"
 )
 
 func TestAccSSHKeyCreate(t *testing.T) {
 	t.Parallel()
 
-	sshKeyName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	sshKeyName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -35,6 +34,37 @@ func TestAccSSHKeyCreate(t *testing.T) {
 	})
 }
 
+func TestAccSSHKeyCreateInDefaultEnvironment(t *testing.T) {
+	t.Parallel()
+
+	sshKeyName := testAccResourceName(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckSSHKeyCreateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSSHKeyCreateInDefaultEnvironment(environmentID, sshKeyName),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckSSHKeyCreateExists("hci_ssh_key.foobar"),
+					resource.TestCheckResourceAttr("hci_ssh_key.foobar", "environment_id", environmentID),
+				),
+			},
+		},
+	})
+}
+
+func testAccSSHKeyCreateInDefaultEnvironment(environment, name string) string {
+	config := testAccSSHKeyCreate(environment, name)
+	config = strings.Replace(config, fmt.Sprintf("\tenvironment_id = \"%s\"\n", environment), "", 1)
+	return fmt.Sprintf(`
+provider "hci" {
+	default_environment_id = "%s"
+}
+%s`, environment, config)
+}
+
 func testAccSSHKeyCreate(environment, name string) string {
 	bitSize := 4096
 
@@ -71,8 +101,8 @@ func testAccCheckSSHKeyCreateExists(name string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -91,7 +121,7 @@ func testAccCheckSSHKeyCreateExists(name string) resource.TestCheckFunc {
 }
 
 func testAccCheckSSHKeyCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_ssh_key" {
@@ -99,7 +129,7 @@ func testAccCheckSSHKeyCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -136,3 +166,34 @@ func generatePublicKey(privatekey *rsa.PublicKey) ([]byte, error) {
 
 	return pubKeyBytes, nil
 }
+
+func TestUnitSSHKeyLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_ssh_key", "sshkeys"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(`
+resource "hci_ssh_key" "foobar" {
+	name       = "foobar"
+	public_key = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC foobar"
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_ssh_key.foobar", "id", "foobar"),
+					fake.checkEntity("hci_ssh_key.foobar", "sshkeys", "publicKey", "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQC foobar"),
+				),
+			},
+			{
+				ResourceName:            "hci_ssh_key.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"public_key"},
+			},
+		},
+	})
+}
@@ -1,27 +1,37 @@
 package hci
 
 import (
-	"fmt"
+	"context"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciStaticNAT() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciStaticNATCreate,
-		Read:   resourceHciStaticNATRead,
-		Delete: resourceHciStaticNATDelete,
+		CreateContext: resourceHciStaticNATCreate,
+		ReadContext:   resourceHciStaticNATRead,
+		DeleteContext: resourceHciStaticNATDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where static NAT should be enabled",
 			},
@@ -41,11 +51,11 @@ func resourceHciStaticNAT() *schema.Resource {
 	}
 }
 
-func resourceHciStaticNATCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciStaticNATCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	staticNATPublicIP := hci.PublicIp{
 		Id:          d.Get("public_ip_id").(string),
@@ -53,21 +63,21 @@ func resourceHciStaticNATCreate(d *schema.ResourceData, meta interface{}) error
 	}
 	_, err := hciResources.PublicIps.EnableStaticNat(staticNATPublicIP)
 	if err != nil {
-		return fmt.Errorf("Error enabling static NAT: %s", err)
+		return diagFromErrorf(err, "Error enabling static NAT")
 	}
 	d.SetId(staticNATPublicIP.Id)
-	return resourceHciStaticNATRead(d, meta)
+	return resourceHciStaticNATRead(ctx, d, meta)
 }
 
-func resourceHciStaticNATRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciStaticNATRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	publicIP, err := hciResources.PublicIps.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("Static NAT", false, err, d)
+		return diagFromError(handleNotFoundError("Static NAT", false, err, d))
 	}
 	if publicIP.PrivateIpId == "" {
 		// If the private IP ID is missing, it means the public IP no longer has static NAT
@@ -76,17 +86,17 @@ func resourceHciStaticNATRead(d *schema.ResourceData, meta interface{}) error {
 		return nil
 	}
 	if err := d.Set("private_ip_id", publicIP.PrivateIpId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	return nil
 }
 
-func resourceHciStaticNATDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciStaticNATDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	_, err := hciResources.PublicIps.DisableStaticNat(d.Id())
-	return handleNotFoundError("Static NAT", true, err, d)
+	return diagFromError(handleNotFoundError("Static NAT", true, err, d))
 }
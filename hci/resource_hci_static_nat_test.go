@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccStaticNATCreate(t *testing.T) {
 	t.Parallel()
 
-	instanceName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	instanceName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -67,8 +66,8 @@ func testAccCheckStaticNATCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -88,7 +87,7 @@ func testAccCheckStaticNATCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckStaticNATCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_static_nat" {
@@ -96,7 +95,7 @@ func testAccCheckStaticNATCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -110,3 +109,38 @@ func testAccCheckStaticNATCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitStaticNATLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_public_ip", "publicipaddresses"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(fakeInstanceConfig + `
+resource "hci_public_ip" "foobar" {
+	vpc_id = hci_vpc.fake.id
+}
+
+resource "hci_static_nat" "foobar" {
+	public_ip_id  = hci_public_ip.foobar.id
+	private_ip_id = hci_instance.fake.private_ip_id
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("hci_static_nat.foobar", "id", "hci_public_ip.foobar", "id"),
+					fake.checkEntity("hci_public_ip.foobar", "publicipaddresses", "purposes", []string{"STATIC_NAT"}),
+				),
+			},
+			{
+				ResourceName:            "hci_static_nat.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"public_ip_id"},
+			},
+		},
+	})
+}
@@ -1,30 +1,42 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciVolume() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciVolumeCreate,
-		Read:   resourceHciVolumeRead,
-		Update: resourceHciVolumeUpdate,
-		Delete: resourceHciVolumeDelete,
+		CreateContext: resourceHciVolumeCreate,
+		ReadContext:   resourceHciVolumeRead,
+		UpdateContext: resourceHciVolumeUpdate,
+		DeleteContext: resourceHciVolumeDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where the volume should be created",
 			},
@@ -60,19 +72,29 @@ func resourceHciVolume() *schema.Resource {
 				Required:    true,
 				Description: "The id of the instance to which the volume will be attached",
 			},
+			"zone": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Name or id of the zone in which the volume should be created",
+				StateFunc: func(val interface{}) string {
+					return strings.ToLower(val.(string))
+				},
+			},
 		},
 	}
 }
 
-func resourceHciVolumeCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVolumeCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	diskOffering, err := retrieveDiskOffering(&hciResources, d.Get("disk_offering").(string))
 	if err != nil {
-		return err
+		return diagFromError(err)
 	}
 	volumeToCreate := hci.Volume{
 		Name:           d.Get("name").(string),
@@ -81,26 +103,22 @@ func resourceHciVolumeCreate(d *schema.ResourceData, meta interface{}) error {
 
 	if val, ok := d.GetOk("size_in_gb"); ok {
 		if !diskOffering.CustomSize {
-			return fmt.Errorf("Disk offering %s doesn't allow custom size", diskOffering.Id)
+			return diag.Errorf("Disk offering %s doesn't allow custom size", diskOffering.Id)
 		}
 		volumeToCreate.GbSize = val.(int)
 	}
 
 	if val, ok := d.GetOk("iops"); ok {
 		if !diskOffering.CustomIops {
-			return fmt.Errorf("Disk offering %s doesn't allow custom IOPS", diskOffering.Id)
+			return diag.Errorf("Disk offering %s doesn't allow custom IOPS", diskOffering.Id)
 		}
 		volumeToCreate.Iops = val.(int)
 	}
 
 	if zone, ok := d.GetOk("zone"); ok {
-		if isID(zone.(string)) {
-			volumeToCreate.ZoneId = zone.(string)
-		} else {
-			volumeToCreate.ZoneId, err = retrieveZoneID(&hciResources, zone.(string))
-			if err != nil {
-				return err
-			}
+		volumeToCreate.ZoneId, err = retrieveZoneID(&hciResources, zone.(string))
+		if err != nil {
+			return diagFromError(err)
 		}
 	}
 
@@ -110,56 +128,60 @@ func resourceHciVolumeCreate(d *schema.ResourceData, meta interface{}) error {
 
 	newVolume, err := hciResources.Volumes.Create(volumeToCreate)
 	if err != nil {
-		return err
+		return diagFromError(err)
 	}
 	d.SetId(newVolume.Id)
-	return resourceHciVolumeRead(d, meta)
+	return resourceHciVolumeRead(ctx, d, meta)
 }
 
-func resourceHciVolumeRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVolumeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	volume, err := hciResources.Volumes.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("Volume", false, err, d)
+		return diagFromError(handleNotFoundError("Volume", false, err, d))
 	}
 
 	if err := d.Set("name", volume.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := setValueOrID(d, "disk_offering", strings.ToLower(volume.DiskOfferingName), volume.DiskOfferingId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("size_in_gb", volume.GbSize); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("iops", volume.Iops); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("instance_id", volume.InstanceId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
+	}
+
+	if err := setValueOrID(d, "zone", strings.ToLower(volume.ZoneName), volume.ZoneId); err != nil {
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciVolumeUpdate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVolumeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	d.Partial(true)
 	curVolume, err := hciResources.Volumes.Get(d.Id())
 	if err != nil {
-		return err
+		return diagFromError(err)
 	}
 	if d.HasChange("instance_id") {
 		oldInstanceID, newInstanceID := d.GetChange("instance_id")
@@ -169,13 +191,13 @@ func resourceHciVolumeUpdate(d *schema.ResourceData, meta interface{}) error {
 		if oldInstanceID != "" && curVolume.InstanceId != "" {
 			err := hciResources.Volumes.DetachFromInstance(volume)
 			if err != nil {
-				return err
+				return diagFromError(err)
 			}
 		}
 		if newInstanceID != "" {
 			err := hciResources.Volumes.AttachToInstance(volume, newInstanceID.(string))
 			if err != nil {
-				return err
+				return diagFromError(err)
 			}
 		}
 	}
@@ -186,7 +208,7 @@ func resourceHciVolumeUpdate(d *schema.ResourceData, meta interface{}) error {
 		if val, ok := d.GetOk("size_in_gb"); ok {
 			volumeToResize.GbSize = val.(int)
 			if curVolume.GbSize > volumeToResize.GbSize {
-				return fmt.Errorf("Cannot reduce size of a volume")
+				return diag.Errorf("Cannot reduce size of a volume")
 			}
 		}
 		if val, ok := d.GetOk("iops"); ok {
@@ -195,14 +217,14 @@ func resourceHciVolumeUpdate(d *schema.ResourceData, meta interface{}) error {
 		_ = hciResources.Volumes.Resize(&volumeToResize)
 	}
 	d.Partial(false)
-	return resourceHciVolumeRead(d, meta)
+	return resourceHciVolumeRead(ctx, d, meta)
 }
 
-func resourceHciVolumeDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVolumeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if instanceID, ok := d.GetOk("instance_id"); ok && instanceID != "" {
 		volume := &hci.Volume{
@@ -210,16 +232,19 @@ func resourceHciVolumeDelete(d *schema.ResourceData, meta interface{}) error {
 		}
 		err := hciResources.Volumes.DetachFromInstance(volume)
 		if err != nil {
-			return err
+			return diagFromError(err)
 		}
 	}
 	if err := hciResources.Volumes.Delete(d.Id()); err != nil {
-		return handleNotFoundError("Volume", true, err, d)
+		return diagFromError(handleNotFoundError("Volume", true, err, d))
 	}
 	return nil
 }
 
 func retrieveZoneID(hciResources *hci.Resources, zoneName string) (zoneID string, nerr error) {
+	if isID(zoneName) {
+		return zoneName, nil
+	}
 	zones, err := hciResources.Zones.List()
 	if err != nil {
 		return "", err
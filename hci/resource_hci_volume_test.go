@@ -1,20 +1,19 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccVolumeCreate(t *testing.T) {
 	t.Parallel()
 
 	instanceID := "6f26111d-464d-4fc8-9c72-7a181a96c257"
-	volumeName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	volumeName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -57,8 +56,8 @@ func testAccCheckVolumeCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -78,7 +77,7 @@ func testAccCheckVolumeCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckVolumeCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_volume" {
@@ -86,7 +85,7 @@ func testAccCheckVolumeCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -100,3 +99,48 @@ func testAccCheckVolumeCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitVolumeLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_volume", "volumes"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(testUnitVolume(30, 200)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("hci_volume.foobar", "instance_id", "hci_instance.fake", "id"),
+					fake.checkEntity("hci_volume.foobar", "volumes", "sizeInGb", 30),
+					fake.checkEntity("hci_volume.foobar", "volumes", "iops", 200),
+				),
+			},
+			{
+				Config: fake.config(testUnitVolume(40, 300)),
+				Check: resource.ComposeTestCheckFunc(
+					fake.checkEntity("hci_volume.foobar", "volumes", "sizeInGb", 40),
+					fake.checkEntity("hci_volume.foobar", "volumes", "iops", 300),
+				),
+			},
+			{
+				ResourceName:      "hci_volume.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testUnitVolume(sizeInGb, iops int) string {
+	return fmt.Sprintf(fakeInstanceConfig+`
+resource "hci_volume" "foobar" {
+	name          = "foobar"
+	disk_offering = "Custom Disk"
+	size_in_gb    = %d
+	iops          = %d
+	instance_id   = hci_instance.fake.id
+}`, sizeInGb, iops)
+}
@@ -1,31 +1,43 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/api"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciVpc() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciVpcCreate,
-		Read:   resourceHciVpcRead,
-		Update: resourceHciVpcUpdate,
-		Delete: resourceHciVpcDelete,
+		CreateContext: resourceHciVpcCreate,
+		ReadContext:   resourceHciVpcRead,
+		UpdateContext: resourceHciVpcUpdate,
+		DeleteContext: resourceHciVpcDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of environment where VPC should be created",
 			},
@@ -66,16 +78,16 @@ func resourceHciVpc() *schema.Resource {
 	}
 }
 
-func resourceHciVpcCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpcCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	vpcOfferingID, cerr := retrieveVpcOfferingID(&hciResources, d.Get("vpc_offering").(string))
 
 	if cerr != nil {
-		return cerr
+		return diagFromError(cerr)
 	}
 
 	vpcToCreate := hci.Vpc{
@@ -95,34 +107,34 @@ func resourceHciVpcCreate(d *schema.ResourceData, meta interface{}) error {
 			var zErr error
 			vpcToCreate.ZoneId, zErr = retrieveZoneID(&hciResources, zone.(string))
 			if zErr != nil {
-				return zErr
+				return diagFromError(zErr)
 			}
 		}
 	}
 
 	newVpc, err := hciResources.Vpcs.Create(vpcToCreate)
 	if err != nil {
-		return fmt.Errorf("Error creating the new VPC %s: %s", vpcToCreate.Name, err)
+		return diagFromErrorf(err, "Error creating the new VPC %s", vpcToCreate.Name)
 	}
 	d.SetId(newVpc.Id)
 
-	return resourceHciVpcRead(d, meta)
+	return resourceHciVpcRead(ctx, d, meta)
 }
 
-func resourceHciVpcRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpcRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	// Get the vpc details
 	vpc, err := hciResources.Vpcs.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("VPC", false, err, d)
+		return diagFromError(handleNotFoundError("VPC", false, err, d))
 	}
 
 	if err := setValueOrID(d, "zone", vpc.ZoneName, vpc.ZoneId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	vpcOffering, offErr := hciResources.VpcOfferings.Get(vpc.VpcOfferingId)
@@ -134,34 +146,34 @@ func resourceHciVpcRead(d *schema.ResourceData, meta interface{}) error {
 				return nil
 			}
 		}
-		return offErr
+		return diagFromError(offErr)
 	}
 
 	// Update the config
 	if err := d.Set("name", vpc.Name); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("description", vpc.Description); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := setValueOrID(d, "vpc_offering", strings.ToLower(vpcOffering.Name), vpc.VpcOfferingId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	if err := d.Set("network_domain", vpc.NetworkDomain); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 
 	return nil
 }
 
-func resourceHciVpcUpdate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpcUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if d.HasChange("name") || d.HasChange("description") {
 		newName := d.Get("name").(string)
@@ -169,22 +181,22 @@ func resourceHciVpcUpdate(d *schema.ResourceData, meta interface{}) error {
 		log.Printf("[DEBUG] Details have changed updating VPC.....")
 		_, err := hciResources.Vpcs.Update(hci.Vpc{Id: d.Id(), Name: newName, Description: newDescription})
 		if err != nil {
-			return err
+			return diagFromError(err)
 		}
 	}
 
 	return nil
 }
 
-func resourceHciVpcDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpcDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	fmt.Printf("[INFO] Destroying VPC: %s\n", d.Get("name").(string))
 	if _, err := hciResources.Vpcs.Destroy(d.Id()); err != nil {
-		return handleNotFoundError("VPC", true, err, d)
+		return diagFromError(handleNotFoundError("VPC", true, err, d))
 	}
 
 	return nil
@@ -1,19 +1,18 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccVPCCreate(t *testing.T) {
 	t.Parallel()
 
-	vpcName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	vpcName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -55,8 +54,8 @@ func testAccCheckVPCCreateExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -76,7 +75,7 @@ func testAccCheckVPCCreateExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckVPCCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_vpc" {
@@ -84,7 +83,7 @@ func testAccCheckVPCCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -98,3 +97,46 @@ func testAccCheckVPCCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitVPCLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_vpc", "vpcs"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(testUnitVPC("foobar", "This is a foobar vpc")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_vpc.foobar", "environment_id", fake.environmentID),
+					resource.TestCheckResourceAttr("hci_vpc.foobar", "zone", fakeZone),
+					fake.checkEntity("hci_vpc.foobar", "vpcs", "description", "This is a foobar vpc"),
+				),
+			},
+			{
+				Config: fake.config(testUnitVPC("renamed", "This is a renamed vpc")),
+				Check: resource.ComposeTestCheckFunc(
+					fake.checkEntity("hci_vpc.foobar", "vpcs", "name", "renamed"),
+					fake.checkEntity("hci_vpc.foobar", "vpcs", "description", "This is a renamed vpc"),
+				),
+			},
+			{
+				ResourceName:      "hci_vpc.foobar",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testUnitVPC(name, description string) string {
+	return fmt.Sprintf(`
+resource "hci_vpc" "foobar" {
+	name         = "%s"
+	description  = "%s"
+	vpc_offering = "Default VPC offering"
+}`, name, description)
+}
@@ -1,28 +1,38 @@
 package hci
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/api"
 )
 
 func resourceHciVpn() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciVpnCreate,
-		Read:   resourceHciVpnRead,
-		Delete: resourceHciVpnDelete,
+		CreateContext: resourceHciVpnCreate,
+		ReadContext:   resourceHciVpnRead,
+		DeleteContext: resourceHciVpnDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of the environment where the vpn should be created",
 			},
@@ -72,11 +82,11 @@ func resourceHciVpn() *schema.Resource {
 	}
 }
 
-func resourceHciVpnCreate(d *schema.ResourceData, meta interface{}) error {
+func resourceHciVpnCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	vpnIPPurpose := "SOURCE_NAT"
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	var vpnPubIPID string
@@ -96,59 +106,59 @@ func resourceHciVpnCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	if vpnPubIPID == "" {
-		return fmt.Errorf("Error enabling the VPN because no Source NAT IP was found for the VPC")
+		return diag.Errorf("Error enabling the VPN because no Source NAT IP was found for the VPC")
 	}
 
 	_, err := hciResources.RemoteAccessVpn.Enable(vpnPubIPID)
 	if err != nil {
-		return fmt.Errorf("Error enabling the VPN: %s", err)
+		return diagFromErrorf(err, "Error enabling the VPN")
 	}
 	d.SetId(vpnPubIPID)
-	return resourceHciVpnRead(d, meta)
+	return resourceHciVpnRead(ctx, d, meta)
 }
 
-func resourceHciVpnRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpnRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	vpn, err := hciResources.RemoteAccessVpn.Get(d.Id())
 	if err != nil {
-		return handleNotFoundError("VPN", false, err, d)
+		return diagFromError(handleNotFoundError("VPN", false, err, d))
 	}
 
 	if vpn.State == "Disabled" {
 		// If the VPN is disabled, it means the VPN is not active
 		// so this entity is "missing" (at least as far as terraform is concerned).
 		d.SetId("")
-		return handleNotFoundError("VPN Disabled", false, err, d)
+		return diagFromError(handleNotFoundError("VPN Disabled", false, err, d))
 	}
 	if err := d.Set("state", vpn.State); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	if err := d.Set("certificate", vpn.Certificate); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	if err := d.Set("preshared_key", vpn.PresharedKey); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	if err := d.Set("public_ip", vpn.PublicIpAddress); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	if err := d.Set("public_ip_id", vpn.PublicIpAddressId); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	if err := d.Set("type", vpn.Type); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	return nil
 }
 
-func resourceHciVpnDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpnDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	if _, err := hciResources.RemoteAccessVpn.Disable(d.Id()); err != nil {
 		if hciError, ok := err.(api.HciErrorResponse); ok {
@@ -157,9 +167,9 @@ func resourceHciVpnDelete(d *schema.ResourceData, meta interface{}) error {
 				d.SetId("")
 				return nil
 			}
-			return handleNotFoundError("VPN Delete", true, err, d)
+			return diagFromError(handleNotFoundError("VPN Delete", true, err, d))
 		}
-		return handleNotFoundError("VPN Delete", true, err, d)
+		return diagFromError(handleNotFoundError("VPN Delete", true, err, d))
 	}
 	return nil
 }
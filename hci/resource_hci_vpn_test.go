@@ -1,12 +1,12 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccRemoteAccessVPNEnable(t *testing.T) {
@@ -53,8 +53,8 @@ func testAccCheckRemoteAccessVPNEnableExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -74,7 +74,7 @@ func testAccCheckRemoteAccessVPNEnableExists(n string) resource.TestCheckFunc {
 }
 
 func testAccCheckRemoteAccessVPNEnableDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_vpn" {
@@ -82,7 +82,7 @@ func testAccCheckRemoteAccessVPNEnableDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -96,3 +96,44 @@ func testAccCheckRemoteAccessVPNEnableDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitRemoteAccessVPNLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(fakeNetworkConfig + `
+resource "hci_vpn" "foobar" {
+	vpc_id = hci_vpc.fake.id
+}`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("hci_vpn.foobar", "state", "Running"),
+					resource.TestCheckResourceAttrSet("hci_vpn.foobar", "preshared_key"),
+					fake.checkEntity("hci_vpn.foobar", "remoteaccessvpns", "state", "Running"),
+				),
+			},
+			{
+				ResourceName:            "hci_vpn.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"vpc_id"},
+			},
+			{
+				Config: fake.config(fakeNetworkConfig),
+				Check: func(s *terraform.State) error {
+					for _, vpn := range fake.all("remoteaccessvpns") {
+						if vpn["state"] != DISABLED {
+							return fmt.Errorf("VPN %s is still enabled", vpn["id"])
+						}
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
@@ -1,29 +1,39 @@
 package hci
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	hc "github.com/hypertec-cloud/go-hci"
 	"github.com/hypertec-cloud/go-hci/api"
 	"github.com/hypertec-cloud/go-hci/services/hci"
 )
 
 func resourceHciVpnUser() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceHciVpnUserCreate,
-		Read:   resourceHciVpnUserRead,
-		Delete: resourceHciVpnUserDelete,
+		CreateContext: resourceHciVpnUserCreate,
+		ReadContext:   resourceHciVpnUserRead,
+		DeleteContext: resourceHciVpnUserDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importStateWithDefaultEnvironment,
 		},
 
+		CustomizeDiff: customizeDiffDefaultEnvironment,
+
 		Schema: map[string]*schema.Schema{
 			"environment_id": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Computed:    true,
 				ForceNew:    true,
 				Description: "ID of the environment where the vpn should be created",
 			},
@@ -43,10 +53,10 @@ func resourceHciVpnUser() *schema.Resource {
 	}
 }
 
-func resourceHciVpnUserCreate(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpnUserCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	remoteAccessVpnUser := hci.RemoteAccessVpnUser{
@@ -55,7 +65,7 @@ func resourceHciVpnUserCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	_, err := hciResources.RemoteAccessVpnUser.Create(remoteAccessVpnUser)
 	if err != nil {
-		return fmt.Errorf("Error adding VPN user: %s", err)
+		return diagFromErrorf(err, "Error adding VPN user")
 	}
 
 	// TODO: When the CMC API actually returns the ID of the created user, use it.
@@ -63,7 +73,7 @@ func resourceHciVpnUserCreate(d *schema.ResourceData, meta interface{}) error {
 	// we have to list all users and then loop through to match the username in order to find the ID.
 	vpnUsers, err := hciResources.RemoteAccessVpnUser.List()
 	if err != nil {
-		return fmt.Errorf("Error getting the created VPN user ID: %s", err)
+		return diagFromErrorf(err, "Error getting the created VPN user ID")
 	}
 	var userID string
 	for _, user := range vpnUsers {
@@ -75,15 +85,15 @@ func resourceHciVpnUserCreate(d *schema.ResourceData, meta interface{}) error {
 	if userID != "" {
 		d.SetId(userID)
 	} else {
-		return fmt.Errorf("Error finding the created VPN user ID: %s", err)
+		return diagFromErrorf(err, "Error finding the created VPN user ID")
 	}
-	return resourceHciVpnUserRead(d, meta)
+	return resourceHciVpnUserRead(ctx, d, meta)
 }
 
-func resourceHciVpnUserRead(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpnUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 
 	// Get the user based on the ID
@@ -97,15 +107,15 @@ func resourceHciVpnUserRead(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	if err := d.Set("username", vpnUser.Username); err != nil {
-		return fmt.Errorf("Error reading Trigger: %s", err)
+		return diag.Errorf("Error reading Trigger: %s", err)
 	}
 	return nil
 }
 
-func resourceHciVpnUserDelete(d *schema.ResourceData, meta interface{}) error {
-	hciResources, rerr := getResourcesForEnvironmentID(meta.(*hc.HciClient), d.Get("environment_id").(string))
+func resourceHciVpnUserDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	hciResources, rerr := getResourcesForEnvironmentID(ctx, meta, d.Get("environment_id").(string))
 	if rerr != nil {
-		return rerr
+		return diagFromError(rerr)
 	}
 	remoteAccessVpnUser := hci.RemoteAccessVpnUser{
 		Id:       d.Id(),
@@ -118,9 +128,9 @@ func resourceHciVpnUserDelete(d *schema.ResourceData, meta interface{}) error {
 				d.SetId("")
 				return nil
 			}
-			return handleNotFoundError("VPN User Delete", true, err, d)
+			return diagFromError(handleNotFoundError("VPN User Delete", true, err, d))
 		}
-		return handleNotFoundError("VPN User Delete", true, err, d)
+		return diagFromError(handleNotFoundError("VPN User Delete", true, err, d))
 	}
 	return nil
 }
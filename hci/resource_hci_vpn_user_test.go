@@ -1,13 +1,12 @@
 package hci
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-	hci "github.com/hypertec-cloud/go-hci"
 )
 
 func TestAccRemoteAccessVPNUserCreate(t *testing.T) {
@@ -18,7 +17,7 @@ func TestAccRemoteAccessVPNUserCreate(t *testing.T) {
 
 	environmentID := "c67a090f-b66f-42e1-b444-10cdff9d8be2"
 	vpcID := "2c01d952-d010-4811-b66d-4c7f5f805193"
-	vpnUserName := fmt.Sprintf("terraform-test-%s", acctest.RandString(10))
+	vpnUserName := testAccResourceName(t)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -63,8 +62,8 @@ func testAccCheckRemoteAccessVPNUserCreateExists(n string) resource.TestCheckFun
 			return fmt.Errorf("Environment ID is missing")
 		}
 
-		client := testAccProvider.Meta().(*hci.HciClient)
-		resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+		meta := testAccProvider.Meta()
+		resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 		if err != nil {
 			return err
 		}
@@ -84,7 +83,7 @@ func testAccCheckRemoteAccessVPNUserCreateExists(n string) resource.TestCheckFun
 }
 
 func testAccCheckRemoteAccessVPNUserCreateDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*hci.HciClient)
+	meta := testAccProvider.Meta()
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type == "hci_vpn_user" {
@@ -92,7 +91,7 @@ func testAccCheckRemoteAccessVPNUserCreateDestroy(s *terraform.State) error {
 				return fmt.Errorf("Environment ID is missing")
 			}
 
-			resources, err := getResourcesForEnvironmentID(client, rs.Primary.Attributes["environment_id"])
+			resources, err := getResourcesForEnvironmentID(context.Background(), meta, rs.Primary.Attributes["environment_id"])
 			if err != nil {
 				return err
 			}
@@ -106,3 +105,31 @@ func testAccCheckRemoteAccessVPNUserCreateDestroy(s *terraform.State) error {
 
 	return nil
 }
+
+func TestUnitRemoteAccessVPNUserLifecycle(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeAPI(t)
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:          func() { testUnitPreCheck(t) },
+		ProviderFactories: fake.providerFactories(),
+		CheckDestroy:      fake.checkDestroy("hci_vpn_user", "vpnusers"),
+		Steps: []resource.TestStep{
+			{
+				Config: fake.config(`
+resource "hci_vpn_user" "foobar" {
+	username = "foobar"
+	password = "Passw0rd!"
+}`),
+				Check: fake.checkEntity("hci_vpn_user.foobar", "vpnusers", "username", "foobar"),
+			},
+			{
+				ResourceName:            "hci_vpn_user.foobar",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password"},
+			},
+		},
+	})
+}
@@ -0,0 +1,653 @@
+package hci
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	hc "github.com/hypertec-cloud/go-hci"
+	"github.com/hypertec-cloud/go-hci/services/hci"
+)
+
+// Prefix of the names of the resources created by the acceptance tests
+const testAccResourcePrefix = "terraform-test-"
+
+// Sweepers delete the resources leaked by failed acceptance tests. The region of the sweepers is the id of
+// the environment to sweep, e.g. go test ./hci -sweep=<environment id>. The client is configured like the
+// provider, with HCI_API_KEY, HCI_API_URL or a profile of the credentials file.
+//
+// Sweepers are listed in the order they run: rules using public IPs and instances first, then public IPs,
+// volumes, instances, networks, ACLs and VPCs.
+var sweepers = []*resource.Sweeper{
+	{Name: "hci_load_balancer_rule", F: sweepLoadBalancerRules},
+	{Name: "hci_port_forwarding_rule", F: sweepPortForwardingRules},
+	{
+		Name:         "hci_static_nat",
+		Dependencies: []string{"hci_load_balancer_rule", "hci_port_forwarding_rule"},
+		F:            sweepStaticNATs,
+	},
+	{Name: "hci_public_ip", Dependencies: []string{"hci_static_nat"}, F: sweepPublicIPs},
+	{Name: "hci_volume", Dependencies: []string{"hci_public_ip"}, F: sweepVolumes},
+	{Name: "hci_instance", Dependencies: []string{"hci_volume"}, F: sweepInstances},
+	{Name: "hci_affinity_group", Dependencies: []string{"hci_instance"}, F: sweepAffinityGroups},
+	{Name: "hci_ssh_key", Dependencies: []string{"hci_instance"}, F: sweepSSHKeys},
+	{Name: "hci_network", Dependencies: []string{"hci_instance"}, F: sweepNetworks},
+	{Name: "hci_network_acl_rule", F: sweepNetworkACLRules},
+	{Name: "hci_network_acl", Dependencies: []string{"hci_network", "hci_network_acl_rule"}, F: sweepNetworkACLs},
+	{Name: "hci_vpn_user", F: sweepVpnUsers},
+	{Name: "hci_vpn", Dependencies: []string{"hci_vpn_user"}, F: sweepVpns},
+	{Name: "hci_vpc", Dependencies: []string{"hci_network_acl", "hci_public_ip", "hci_vpn"}, F: sweepVpcs},
+	{
+		Name:         "hci_environment",
+		Dependencies: []string{"hci_vpc", "hci_affinity_group", "hci_ssh_key"},
+		F:            sweepEnvironments,
+	},
+}
+
+func init() {
+	for _, sweeper := range sweepers {
+		resource.AddTestSweepers(sweeper.Name, sweeper)
+	}
+}
+
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+func isTestResourceName(name string) bool {
+	return strings.HasPrefix(name, testAccResourcePrefix)
+}
+
+// Returns the meta of a provider configured from the environment variables and the credentials file
+func sweeperMeta() (interface{}, error) {
+	provider := Provider()
+	diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{}))
+	for _, d := range diags {
+		if d.Severity == diag.Error {
+			return nil, fmt.Errorf("Error configuring the provider: %s", d.Summary)
+		}
+	}
+	return provider.Meta(), nil
+}
+
+func sweeperClient() (*hc.HciClient, error) {
+	meta, err := sweeperMeta()
+	if err != nil {
+		return nil, err
+	}
+	return getClient(context.Background(), meta), nil
+}
+
+// Returns the resources of the environment to sweep
+func sweeperResources(environmentID string) (hci.Resources, error) {
+	meta, err := sweeperMeta()
+	if err != nil {
+		return hci.Resources{}, err
+	}
+	return getResourcesForEnvironmentID(context.Background(), meta, environmentID)
+}
+
+// Deletes the entities with the ids, going on when one can't be deleted
+func sweep(entityType string, ids []string, delete func(id string) error) error {
+	var failures []string
+	for _, id := range ids {
+		log.Printf("[INFO] Sweeping %s %s", entityType, id)
+		if err := delete(id); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", id, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("Error sweeping %d %s: %s", len(failures), entityType, strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+// Returns the ids of the instances created by the acceptance tests
+func testInstanceIDs(hciResources hci.Resources) (map[string]bool, error) {
+	instances, err := hciResources.Instances.List()
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for _, instance := range instances {
+		if isTestResourceName(instance.Name) {
+			ids[instance.Id] = true
+		}
+	}
+	return ids, nil
+}
+
+func sweepLoadBalancerRules(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	rules, err := hciResources.LoadBalancerRules.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, rule := range rules {
+		if isTestResourceName(rule.Name) {
+			ids = append(ids, rule.Id)
+		}
+	}
+	return sweep("load balancer rules", ids, func(id string) error {
+		return hciResources.LoadBalancerRules.Delete(id)
+	})
+}
+
+// Port forwarding rules have no name, the ones forwarding to the instances of the tests are deleted
+func sweepPortForwardingRules(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	rules, err := hciResources.PortForwardingRules.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, rule := range rules {
+		if isTestResourceName(rule.InstanceName) {
+			ids = append(ids, rule.Id)
+		}
+	}
+	return sweep("port forwarding rules", ids, func(id string) error {
+		_, err := hciResources.PortForwardingRules.Delete(id)
+		return err
+	})
+}
+
+// Static NAT is disabled on the public IPs of the instances of the tests
+func sweepStaticNATs(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	instanceIDs, err := testInstanceIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	publicIPs, err := hciResources.PublicIps.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, publicIP := range publicIPs {
+		if instanceIDs[publicIP.InstanceId] && hasPurpose(publicIP, "STATIC_NAT") {
+			ids = append(ids, publicIP.Id)
+		}
+	}
+	return sweep("static NATs", ids, func(id string) error {
+		_, err := hciResources.PublicIps.DisableStaticNat(id)
+		return err
+	})
+}
+
+// Public IPs have no name. Once the rules and static NATs of the tests are swept, the public IPs used by
+// nothing, which aren't source NAT IPs either, are released if they belong to a VPC or a network of the
+// tests, or are associated with instances of the tests. Free public IPs of other VPCs and networks are kept.
+func sweepPublicIPs(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	vpcIDs, err := testVpcIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	networkIDs, err := testNetworkIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	publicIPs, err := hciResources.PublicIps.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, publicIP := range publicIPs {
+		unused := len(publicIP.Purposes) == 0 && publicIP.InstanceId == ""
+		if unused && isTestPublicIP(publicIP, vpcIDs, networkIDs) {
+			ids = append(ids, publicIP.Id)
+		}
+	}
+	return sweep("public IPs", ids, func(id string) error {
+		_, err := hciResources.PublicIps.Release(id)
+		return err
+	})
+}
+
+// Returns whether the public IP belongs to a VPC or a network of the tests, or is associated with instances of the tests
+func isTestPublicIP(publicIP hci.PublicIp, vpcIDs, networkIDs map[string]bool) bool {
+	if vpcIDs[publicIP.VpcId] || networkIDs[publicIP.NetworkId] ||
+		isTestResourceName(publicIP.VpcName) || isTestResourceName(publicIP.NetworkName) {
+		return true
+	}
+	for _, name := range publicIP.InstanceNames {
+		if isTestResourceName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPurpose(publicIP hci.PublicIp, purpose string) bool {
+	for _, p := range publicIP.Purposes {
+		if strings.EqualFold(p, purpose) {
+			return true
+		}
+	}
+	return false
+}
+
+// Data volumes are detached from their instance before being deleted
+func sweepVolumes(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	volumes, err := hciResources.Volumes.ListOfType(hci.VOLUME_TYPE_DATA)
+	if err != nil {
+		return err
+	}
+	attached := map[string]bool{}
+	var ids []string
+	for _, volume := range volumes {
+		if isTestResourceName(volume.Name) {
+			ids = append(ids, volume.Id)
+			attached[volume.Id] = volume.InstanceId != ""
+		}
+	}
+	return sweep("volumes", ids, func(id string) error {
+		if attached[id] {
+			if err := hciResources.Volumes.DetachFromInstance(&hci.Volume{Id: id}); err != nil {
+				return err
+			}
+		}
+		return hciResources.Volumes.Delete(id)
+	})
+}
+
+// Instances are purged so that their name can be used again right away
+func sweepInstances(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	instanceIDs, err := testInstanceIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for id := range instanceIDs {
+		ids = append(ids, id)
+	}
+	return sweep("instances", ids, func(id string) error {
+		_, err := hciResources.Instances.DestroyWithOptions(id, hci.DestroyOptions{PurgeImmediately: true})
+		return err
+	})
+}
+
+func sweepAffinityGroups(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	affinityGroups, err := hciResources.AffinityGroups.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, affinityGroup := range affinityGroups {
+		if isTestResourceName(affinityGroup.Name) {
+			ids = append(ids, affinityGroup.Id)
+		}
+	}
+	return sweep("affinity groups", ids, func(id string) error {
+		_, err := hciResources.AffinityGroups.Delete(id)
+		return err
+	})
+}
+
+func sweepSSHKeys(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	keys, err := hciResources.SSHKeys.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, key := range keys {
+		if isTestResourceName(key.Name) {
+			ids = append(ids, key.ID)
+		}
+	}
+	return sweep("SSH keys", ids, func(id string) error {
+		_, err := hciResources.SSHKeys.Delete(id)
+		return err
+	})
+}
+
+// Returns the ids of the networks created by the acceptance tests
+func testNetworkIDs(hciResources hci.Resources) (map[string]bool, error) {
+	networks, err := hciResources.Networks.List()
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for _, network := range networks {
+		if isTestResourceName(network.Name) {
+			ids[network.Id] = true
+		}
+	}
+	return ids, nil
+}
+
+func sweepNetworks(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	networkIDs, err := testNetworkIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for id := range networkIDs {
+		ids = append(ids, id)
+	}
+	return sweep("networks", ids, func(id string) error {
+		_, err := hciResources.Networks.Delete(id)
+		return err
+	})
+}
+
+// Returns the ids of the network ACLs created by the acceptance tests
+func testNetworkACLIDs(hciResources hci.Resources) ([]string, error) {
+	acls, err := hciResources.NetworkAcls.List()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, acl := range acls {
+		if isTestResourceName(acl.Name) {
+			ids = append(ids, acl.Id)
+		}
+	}
+	return ids, nil
+}
+
+// Network ACL rules have no name, the rules of the ACLs of the tests are deleted
+func sweepNetworkACLRules(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	aclIDs, err := testNetworkACLIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, aclID := range aclIDs {
+		rules, err := hciResources.NetworkAclRules.ListByNetworkAclId(aclID)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			ids = append(ids, rule.Id)
+		}
+	}
+	return sweep("network ACL rules", ids, func(id string) error {
+		_, err := hciResources.NetworkAclRules.Delete(id)
+		return err
+	})
+}
+
+func sweepNetworkACLs(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	ids, err := testNetworkACLIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	return sweep("network ACLs", ids, func(id string) error {
+		_, err := hciResources.NetworkAcls.Delete(id)
+		return err
+	})
+}
+
+func sweepVpnUsers(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	users, err := hciResources.RemoteAccessVpnUser.List()
+	if err != nil {
+		return err
+	}
+	usernames := map[string]string{}
+	var ids []string
+	for _, user := range users {
+		if isTestResourceName(user.Username) {
+			ids = append(ids, user.Id)
+			usernames[user.Id] = user.Username
+		}
+	}
+	return sweep("VPN users", ids, func(id string) error {
+		_, err := hciResources.RemoteAccessVpnUser.Delete(hci.RemoteAccessVpnUser{Id: id, Username: usernames[id]})
+		return err
+	})
+}
+
+// Returns the ids of the VPCs created by the acceptance tests
+func testVpcIDs(hciResources hci.Resources) (map[string]bool, error) {
+	vpcs, err := hciResources.Vpcs.List()
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for _, vpc := range vpcs {
+		if isTestResourceName(vpc.Name) {
+			ids[vpc.Id] = true
+		}
+	}
+	return ids, nil
+}
+
+// VPNs have no name, the VPNs of the VPCs of the tests are disabled
+func sweepVpns(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	vpcIDs, err := testVpcIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	publicIPs, err := hciResources.PublicIps.List()
+	if err != nil {
+		return err
+	}
+	testPublicIPs := map[string]bool{}
+	for _, publicIP := range publicIPs {
+		testPublicIPs[publicIP.Id] = vpcIDs[publicIP.VpcId]
+	}
+	vpns, err := hciResources.RemoteAccessVpn.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, vpn := range vpns {
+		if testPublicIPs[vpn.PublicIpAddressId] && !strings.EqualFold(vpn.State, "Disabled") {
+			ids = append(ids, vpn.Id)
+		}
+	}
+	return sweep("VPNs", ids, func(id string) error {
+		_, err := hciResources.RemoteAccessVpn.Disable(id)
+		return err
+	})
+}
+
+func sweepVpcs(region string) error {
+	hciResources, err := sweeperResources(region)
+	if err != nil {
+		return err
+	}
+	vpcIDs, err := testVpcIDs(hciResources)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for id := range vpcIDs {
+		ids = append(ids, id)
+	}
+	return sweep("VPCs", ids, func(id string) error {
+		_, err := hciResources.Vpcs.Destroy(id)
+		return err
+	})
+}
+
+// Environments of the tests are swept in the organization of the environment of the region. The environment
+// of the region itself is never swept.
+func sweepEnvironments(region string) error {
+	client, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+	swept, err := client.Environments.Get(region)
+	if err != nil {
+		return err
+	}
+	environments, err := client.Environments.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, environment := range environments {
+		if isTestResourceName(environment.Name) && environment.Id != swept.Id &&
+			strings.EqualFold(environment.Organization.Id, swept.Organization.Id) {
+			ids = append(ids, environment.Id)
+		}
+	}
+	return sweep("environments", ids, func(id string) error {
+		_, err := client.Environments.Delete(id)
+		return err
+	})
+}
+
+func TestSweepers(t *testing.T) {
+	fake := newFakeAPI(t)
+	t.Setenv("HCI_API_URL", fake.server.URL)
+	t.Setenv(hciAPIKey, fakeAPIKey)
+
+	// entities of the tests, and a look-alike of each that must be kept
+	kept := map[string][]string{}
+	swept := map[string][]string{}
+	add := func(entityType string, entity fakeEntity, test bool) string {
+		id := fake.add(entityType, entity)
+		if test {
+			swept[entityType] = append(swept[entityType], id)
+		} else {
+			kept[entityType] = append(kept[entityType], id)
+		}
+		return id
+	}
+	for _, test := range []bool{true, false} {
+		name := "foobar"
+		if test {
+			name = testAccResourcePrefix + "foobar"
+		}
+		vpcID := add("vpcs", fakeEntity{"name": name}, test)
+		sourceNATID := add("publicipaddresses", fakeEntity{"vpcId": vpcID, "purposes": []string{"SOURCE_NAT"}}, test)
+		add("remoteaccessvpns", fakeEntity{
+			"id":                sourceNATID,
+			"publicIpAddressId": sourceNATID,
+			"vpcId":             vpcID,
+			"state":             "Running",
+		}, test)
+		aclID := add("networkacls", fakeEntity{"name": name, "vpcId": vpcID}, test)
+		add("networkaclrules", fakeEntity{"networkAclId": aclID}, test)
+		networkID := add("networks", fakeEntity{"name": name, "vpcId": vpcID}, test)
+		instanceID := add("instances", fakeEntity{"name": name, "state": "Running"}, test)
+		add("volumes", fakeEntity{"name": name, "type": "DATA", "instanceId": instanceID}, test)
+		add("publicipaddresses", fakeEntity{"vpcId": vpcID, "instanceId": instanceID, "purposes": []string{"STATIC_NAT"}}, test)
+		add("publicipaddresses", fakeEntity{"vpcId": vpcID, "purposes": []string{}}, test)
+		add("publicipaddresses", fakeEntity{"instanceNames": []string{name}, "purposes": []string{}}, test)
+		// the fake API doesn't track the purposes of the public IPs of rules
+		ruleIPID := add("publicipaddresses", fakeEntity{"networkId": networkID, "purposes": []string{}}, test)
+		add("portforwardingrules", fakeEntity{"instanceName": name, "instanceId": instanceID, "ipAddressId": ruleIPID}, test)
+		add("loadbalancerrules", fakeEntity{"name": name, "instanceIds": []string{instanceID}, "publicIpId": ruleIPID}, test)
+		add("affinitygroups", fakeEntity{"name": name}, test)
+		add("sshkeys", fakeEntity{"id": name, "name": name}, test)
+		add("vpnusers", fakeEntity{"username": name}, test)
+	}
+	// a free public IP unrelated to the tests
+	add("publicipaddresses", fakeEntity{"purposes": []string{}}, false)
+	// environments of the tests, in the organization of the swept environment and in another one
+	fake.mu.Lock()
+	environment, err := fake.createEnvironment(fakeEntity{
+		"name":              testAccResourcePrefix + "foobar",
+		"organization":      fake.environments[fake.environmentID]["organization"],
+		"serviceConnection": fake.environments[fake.environmentID]["serviceConnection"],
+	})
+	var otherEnvironment fakeEntity
+	if err == nil {
+		otherOrganization := fakeEntity{"id": fake.newID(), "name": "Other", "entryPoint": "other"}
+		fake.organizations = append(fake.organizations, otherOrganization)
+		otherEnvironment, err = fake.createEnvironment(fakeEntity{
+			"name":              testAccResourcePrefix + "other",
+			"organization":      map[string]interface{}{"id": otherOrganization["id"]},
+			"serviceConnection": fake.environments[fake.environmentID]["serviceConnection"],
+		})
+	}
+	fake.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sweeper := range sweepers {
+		if err := sweeper.F(fake.environmentID); err != nil {
+			t.Fatalf("Error running the sweeper %s: %s", sweeper.Name, err)
+		}
+	}
+
+	for entityType, ids := range swept {
+		for _, id := range ids {
+			if fake.get(entityType, id) != nil {
+				t.Errorf("expected %s %s to be swept", entityType, id)
+			}
+		}
+	}
+	for entityType, ids := range kept {
+		for _, id := range ids {
+			if fake.get(entityType, id) == nil {
+				t.Errorf("expected %s %s to be kept", entityType, id)
+			}
+		}
+	}
+	if !fake.executed("remoteaccessvpns", "disable") {
+		t.Error("expected the VPN of the VPC to be disabled")
+	}
+	if !fake.executed("volumes", "detachFromInstance") {
+		t.Error("expected the volume to be detached before being deleted")
+	}
+	if fake.get("environments", str(environment, "id")) != nil {
+		t.Error("expected the environment of the tests to be swept")
+	}
+	if fake.get("environments", fake.environmentID) == nil {
+		t.Error("expected the environment of the fake API to be kept")
+	}
+	if fake.get("environments", str(otherEnvironment, "id")) == nil {
+		t.Error("expected the environment of the tests in another organization to be kept")
+	}
+}
@@ -0,0 +1,79 @@
+package hci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hypertec-cloud/go-hci/services"
+)
+
+func newTestTaskClient(t *testing.T, responses ...string) *providerMeta {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		poll := int(atomic.AddInt32(&polls, 1))
+		if poll > len(responses) {
+			poll = len(responses)
+		}
+		w.Write([]byte(responses[poll-1]))
+	}))
+	t.Cleanup(server.Close)
+	config := Config{APIURL: server.URL, APIKey: "key"}
+	client, err := config.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &providerMeta{client: client}
+}
+
+func TestPollFailedTask(t *testing.T) {
+	meta := newTestTaskClient(t,
+		`{"data":{"id":"task-1","status":"PENDING","created":"2021-01-01","progress":10}}`,
+		`{"data":{"id":"task-1","status":"PENDING","created":"2021-01-01","progress":60}}`,
+		`{"data":{"id":"task-1","status":"FAILED","created":"2021-01-01","result":{"errors":[{"errorCode":"INSUFFICIENT_CAPACITY","message":"No host can run the instance"}]}}}`,
+	)
+	var progress []int
+	_, err := meta.client.Tasks.PollWithProgress(context.Background(), "task-1", 1, func(task services.Task) {
+		progress = append(progress, task.Progress)
+	})
+	var failedTask services.FailedTask
+	if !errors.As(err, &failedTask) {
+		t.Fatalf("expected a failed task, got %v", err)
+	}
+	if len(failedTask.Errors) != 1 || failedTask.Errors[0].ErrorCode != "INSUFFICIENT_CAPACITY" {
+		t.Errorf("unexpected errors %+v", failedTask.Errors)
+	}
+	if err.Error() != "Task id=task-1 failed: INSUFFICIENT_CAPACITY: No host can run the instance" {
+		t.Errorf("unexpected error %s", err)
+	}
+	if len(progress) != 3 || progress[0] != 10 || progress[1] != 60 || progress[2] != -1 {
+		t.Errorf("unexpected progress %v", progress)
+	}
+}
+
+func TestGetInvalidTask(t *testing.T) {
+	for _, response := range []string{
+		`{"data":{"status":"PENDING"}}`,
+		`{"data":{"id":"task-1","status":42}}`,
+		`{"data":"task-1"}`,
+	} {
+		meta := newTestTaskClient(t, response)
+		if _, err := meta.client.Tasks.Get("task-1"); err == nil {
+			t.Errorf("expected an error for the task %s", response)
+		}
+	}
+}
+
+func TestFailedTaskWithMessage(t *testing.T) {
+	meta := newTestTaskClient(t, `{"data":{"id":"task-1","status":"FAILED","result":"Quota exceeded"}}`)
+	task, err := meta.client.Tasks.Get("task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(task.Errors) != 1 || task.Errors[0].Message != "Quota exceeded" {
+		t.Errorf("unexpected errors %+v", task.Errors)
+	}
+}
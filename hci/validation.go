@@ -0,0 +1,122 @@
+package hci
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Protocols of load balancer and port forwarding rules
+var portProtocols = []string{TCP, UDP}
+
+// Load balancing algorithms of load balancer rules
+var loadBalancingAlgorithms = []string{"leastconn", "roundrobin", "source"}
+
+// Returns an error diagnostic about the attribute at the path
+func invalidValue(path cty.Path, format string, args ...interface{}) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       "Invalid value",
+		Detail:        fmt.Sprintf(format, args...),
+		AttributePath: path,
+	}}
+}
+
+// Returns a ValidateDiagFunc accepting one of the values, ignoring case
+func validateOneOf(values ...string) schema.SchemaValidateDiagFunc {
+	return func(val interface{}, path cty.Path) diag.Diagnostics {
+		for _, value := range values {
+			if strings.EqualFold(val.(string), value) {
+				return nil
+			}
+		}
+		return invalidValue(path, "Expected one of %s, got: %q", strings.Join(values, ", "), val.(string))
+	}
+}
+
+// Validates a port number, between 1 and 65535
+func validatePort(val interface{}, path cty.Path) diag.Diagnostics {
+	if _, err := parsePort(val.(string)); err != nil {
+		return invalidValue(path, "%s", err)
+	}
+	return nil
+}
+
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("Expected a port between 1 and 65535, got: %q", value)
+	}
+	return port, nil
+}
+
+// Validates a CIDR block such as 10.0.0.0/24
+func validateCIDR(val interface{}, path cty.Path) diag.Diagnostics {
+	if _, _, err := net.ParseCIDR(val.(string)); err != nil {
+		return invalidValue(path, "Expected a CIDR block such as 10.0.0.0/24, got: %q", val.(string))
+	}
+	return nil
+}
+
+// Validates an ICMP type or code, between 0 and 255 or -1 for all of them
+func validateICMPValue(val interface{}, path cty.Path) diag.Diagnostics {
+	value, err := strconv.Atoi(val.(string))
+	if err != nil || value < -1 || value > 255 {
+		return invalidValue(path, "Expected a number between 0 and 255, or -1 for all, got: %q", val.(string))
+	}
+	return nil
+}
+
+// Returns whether the values of the attributes in the config are known when planning. Unlike
+// ResourceDiff.NewValueKnown, an Optional and Computed attribute missing from the config is known.
+func configValuesKnown(diff *schema.ResourceDiff, keys ...string) bool {
+	config := diff.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return false
+	}
+	for _, key := range keys {
+		if !config.GetAttr(key).IsKnown() {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the string value of the attribute in the config, or "" if it isn't set. The value must be known. Unlike
+// ResourceDiff.Get, an Optional and Computed attribute missing from the config doesn't keep its value from the state.
+func configString(diff *schema.ResourceDiff, key string) string {
+	value := diff.GetRawConfig().GetAttr(key)
+	if value.IsNull() {
+		return ""
+	}
+	return value.AsString()
+}
+
+// Returns the number of ports of the range of the start and end attributes in the config, the end defaulting to the
+// start. Returns 0 if the start isn't set. The values must be known, and ports are validated by validatePort.
+func portRangeLength(diff *schema.ResourceDiff, startKey, endKey string) (int, error) {
+	start, end := configString(diff, startKey), configString(diff, endKey)
+	if start == "" {
+		return 0, nil
+	}
+	if end == "" {
+		end = start
+	}
+	startPort, err := parsePort(start)
+	if err != nil {
+		return 0, err
+	}
+	endPort, err := parsePort(end)
+	if err != nil {
+		return 0, err
+	}
+	if startPort > endPort {
+		return 0, fmt.Errorf("%q (%d) must not be greater than %q (%d)", startKey, startPort, endKey, endPort)
+	}
+	return endPort - startPort + 1, nil
+}
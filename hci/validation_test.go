@@ -0,0 +1,267 @@
+package hci
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// Plans the creation of a resource with the attributes, returning the error of the plan
+func planUnitResource(r *schema.Resource, meta *providerMeta, attributes map[string]cty.Value) error {
+	_, err := planUnitUpdate(r, meta, "", nil, attributes)
+	return err
+}
+
+// Plans the update of the resource with the id and state to the attributes, returning the diff or the error of the plan.
+// Like terraform, the optional computed attributes missing from the attributes keep their value in the state.
+func planUnitUpdate(r *schema.Resource, meta *providerMeta, id string, state map[string]string, attributes map[string]cty.Value) (*terraform.InstanceDiff, error) {
+	block := r.CoreConfigSchema()
+	config, err := block.CoerceValue(cty.ObjectVal(attributes))
+	if err != nil {
+		return nil, err
+	}
+	instanceState := &terraform.InstanceState{ID: id, Attributes: state, RawConfig: config}
+	proposed := config
+	if id != "" {
+		prior, err := instanceState.AttrsAsObjectValue(block.ImpliedType())
+		if err != nil {
+			return nil, err
+		}
+		values := config.AsValueMap()
+		for name, attribute := range block.Attributes {
+			if attribute.Optional && attribute.Computed && values[name].IsNull() {
+				values[name] = prior.GetAttr(name)
+			}
+		}
+		proposed = cty.ObjectVal(values)
+	}
+	return r.Diff(context.Background(), instanceState, terraform.NewResourceConfigShimmed(proposed, block), meta)
+}
+
+func TestValidators(t *testing.T) {
+	for _, test := range []struct {
+		validate schema.SchemaValidateDiagFunc
+		valid    []string
+		invalid  []string
+	}{
+		{validatePort, []string{"1", "80", "65535"}, []string{"0", "65536", "-1", "http", ""}},
+		{validateCIDR, []string{"10.0.0.0/24", "0.0.0.0/0", "2001:db8::/32"}, []string{"10.0.0.0", "10.0.0.0/33", "all"}},
+		{validateICMPValue, []string{"-1", "0", "255"}, []string{"-2", "256", "echo"}},
+		{validateOneOf("Allow", "Deny"), []string{"Allow", "deny"}, []string{"Reject", ""}},
+		{validateOneOf(loadBalancingAlgorithms...), []string{"leastconn", "roundrobin", "source"}, []string{"random"}},
+	} {
+		for _, value := range test.valid {
+			if diags := test.validate(value, cty.GetAttrPath("attribute")); diags.HasError() {
+				t.Errorf("expected %q to be valid, got %+v", value, diags)
+			}
+		}
+		for _, value := range test.invalid {
+			diags := test.validate(value, cty.GetAttrPath("attribute"))
+			if !diags.HasError() {
+				t.Errorf("expected %q to be invalid", value)
+			} else if !diags[0].AttributePath.Equals(cty.GetAttrPath("attribute")) {
+				t.Errorf("expected the error of %q to be about the attribute, got %+v", value, diags[0].AttributePath)
+			}
+		}
+	}
+}
+
+func TestRuleValidationWhenPlanning(t *testing.T) {
+	meta := &providerMeta{defaultEnvironmentID: environmentID}
+	aclRule := func(attributes map[string]cty.Value) map[string]cty.Value {
+		rule := map[string]cty.Value{
+			"rule_number":    cty.StringVal("1"),
+			"cidr":           cty.StringVal("10.0.0.0/24"),
+			"action":         cty.StringVal("Allow"),
+			"protocol":       cty.StringVal("TCP"),
+			"traffic_type":   cty.StringVal("Ingress"),
+			"network_acl_id": cty.StringVal(vpcID),
+		}
+		for key, value := range attributes {
+			rule[key] = value
+		}
+		return rule
+	}
+	portForwardingRule := func(attributes map[string]cty.Value) map[string]cty.Value {
+		rule := map[string]cty.Value{
+			"public_ip_id":       cty.StringVal(vpcID),
+			"private_ip_id":      cty.StringVal(networkID),
+			"protocol":           cty.StringVal("TCP"),
+			"public_port_start":  cty.StringVal("80"),
+			"private_port_start": cty.StringVal("8080"),
+		}
+		for key, value := range attributes {
+			rule[key] = value
+		}
+		return rule
+	}
+
+	for _, test := range []struct {
+		name       string
+		resource   *schema.Resource
+		attributes map[string]cty.Value
+		err        string
+	}{
+		{
+			name:       "TCP ACL rule with ports",
+			resource:   resourceHciNetworkACLRule(),
+			attributes: aclRule(map[string]cty.Value{"start_port": cty.StringVal("80"), "end_port": cty.StringVal("443")}),
+		},
+		{
+			name:     "ICMP ACL rule with a type and code",
+			resource: resourceHciNetworkACLRule(),
+			attributes: aclRule(map[string]cty.Value{
+				"protocol":  cty.StringVal("icmp"),
+				"icmp_type": cty.StringVal("8"),
+				"icmp_code": cty.StringVal("0"),
+			}),
+		},
+		{
+			name:       "ACL rule with an unknown start port",
+			resource:   resourceHciNetworkACLRule(),
+			attributes: aclRule(map[string]cty.Value{"start_port": cty.UnknownVal(cty.String), "end_port": cty.StringVal("443")}),
+		},
+		{
+			name:       "ACL rule with a start port greater than its end port",
+			resource:   resourceHciNetworkACLRule(),
+			attributes: aclRule(map[string]cty.Value{"start_port": cty.StringVal("443"), "end_port": cty.StringVal("80")}),
+			err:        "must not be greater than",
+		},
+		{
+			name:       "ALL ACL rule with ports",
+			resource:   resourceHciNetworkACLRule(),
+			attributes: aclRule(map[string]cty.Value{"protocol": cty.StringVal("ALL"), "start_port": cty.StringVal("80")}),
+			err:        "Cannot have ports",
+		},
+		{
+			name:       "TCP ACL rule with an ICMP type",
+			resource:   resourceHciNetworkACLRule(),
+			attributes: aclRule(map[string]cty.Value{"icmp_type": cty.StringVal("8")}),
+			err:        "Cannot have icmp fields",
+		},
+		{
+			name:       "port forwarding rule of a single port",
+			resource:   resourceHciPortForwardingRule(),
+			attributes: portForwardingRule(nil),
+		},
+		{
+			name:     "port forwarding rule of port ranges",
+			resource: resourceHciPortForwardingRule(),
+			attributes: portForwardingRule(map[string]cty.Value{
+				"public_port_end":  cty.StringVal("89"),
+				"private_port_end": cty.StringVal("8089"),
+			}),
+		},
+		{
+			name:     "port forwarding rule of port ranges of different lengths",
+			resource: resourceHciPortForwardingRule(),
+			attributes: portForwardingRule(map[string]cty.Value{
+				"public_port_end":  cty.StringVal("89"),
+				"private_port_end": cty.StringVal("8080"),
+			}),
+			err: "has 10 ports but the private port range has 1",
+		},
+		{
+			name:       "port forwarding rule with a start port greater than its end port",
+			resource:   resourceHciPortForwardingRule(),
+			attributes: portForwardingRule(map[string]cty.Value{"public_port_end": cty.StringVal("79")}),
+			err:        "must not be greater than",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := planUnitResource(test.resource, meta, test.attributes)
+			if test.err == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestPortForwardingRuleUpdateWhenPlanning(t *testing.T) {
+	meta := &providerMeta{defaultEnvironmentID: environmentID}
+	state := map[string]string{
+		"id":                 "rule",
+		"environment_id":     environmentID,
+		"public_ip_id":       vpcID,
+		"private_ip_id":      networkID,
+		"protocol":           "TCP",
+		"public_port_start":  "80",
+		"public_port_end":    "80",
+		"private_port_start": "80",
+		"private_port_end":   "80",
+	}
+	// the port range ends are missing from the config, so they default to the new starts rather than the old ends
+	_, err := planUnitUpdate(resourceHciPortForwardingRule(), meta, "rule", state, map[string]cty.Value{
+		"environment_id":     cty.StringVal(environmentID),
+		"public_ip_id":       cty.StringVal(vpcID),
+		"private_ip_id":      cty.StringVal(networkID),
+		"protocol":           cty.StringVal("TCP"),
+		"public_port_start":  cty.StringVal("8080"),
+		"private_port_start": cty.StringVal("8080"),
+	})
+	if err != nil {
+		t.Errorf("expected no error, got %s", err)
+	}
+}
+
+func TestRuleSchemaValidation(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		diags  func() bool
+		errors bool
+	}{
+		{"ACL rule", func() bool {
+			return resourceHciNetworkACLRule().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+				"rule_number":    "1",
+				"cidr":           "10.0.0.0/24",
+				"action":         "allow",
+				"protocol":       "all",
+				"traffic_type":   "egress",
+				"network_acl_id": vpcID,
+			})).HasError()
+		}, false},
+		{"ACL rule with an invalid action", func() bool {
+			return resourceHciNetworkACLRule().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+				"rule_number":    "1",
+				"cidr":           "10.0.0.0/24",
+				"action":         "Reject",
+				"protocol":       "TCP",
+				"traffic_type":   "Ingress",
+				"network_acl_id": vpcID,
+			})).HasError()
+		}, true},
+		{"load balancer rule", func() bool {
+			return resourceHciLoadBalancerRule().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":         "foobar",
+				"public_ip_id": vpcID,
+				"network_id":   networkID,
+				"protocol":     "tcp",
+				"algorithm":    "roundrobin",
+				"public_port":  "80",
+				"private_port": "8080",
+			})).HasError()
+		}, false},
+		{"load balancer rule with an invalid algorithm and port", func() bool {
+			return resourceHciLoadBalancerRule().Validate(terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":         "foobar",
+				"public_ip_id": vpcID,
+				"network_id":   networkID,
+				"protocol":     "icmp",
+				"algorithm":    "random",
+				"public_port":  "80",
+				"private_port": "0",
+			})).HasError()
+		}, true},
+	} {
+		if errors := test.diags(); errors != test.errors {
+			t.Errorf("%s: expected errors to be %t, got %t", test.name, test.errors, errors)
+		}
+	}
+}